@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/control"
+)
+
+// ctlUsage descreve os comandos do ctl
+const ctlUsage = `Commands:
+  status                            List monitors and their state
+  queue <monitor>                   Show pending and running jobs of a monitor
+  pause [monitor...]                Stop submitting new files (all monitors if none given)
+  resume [monitor...]               Resume monitors; files that arrived meanwhile are rescanned
+  rescan [monitor...]               Rescan source paths now
+  drain [-timeout 5m] [monitor...]  Pause monitors and wait until their queues are empty
+  reprocess <file>...               Process files again
+  log-level [level]                 Show or change the log level (debug, info, warn, error)
+`
+
+// runCtl implementa "gaa-organizer ctl <command>": envia comandos ao daemon em execução
+// pelo socket de controle (settings.control_socket)
+func runCtl(args []string) int {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to config file (to find control_socket)")
+	socketPath := fs.String("socket", "", "Path to the control socket (overrides the config)")
+	asJSON := fs.Bool("json", false, "Print the raw JSON response")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s ctl [-config config.yaml] [-socket path] [-json] <command> [args]\n", os.Args[0])
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), ctlUsage)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	path := *socketPath
+	if path == "" {
+		cfg, err := config.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			return 2
+		}
+		if cfg.Settings.ControlSocket == "" {
+			fmt.Fprintf(os.Stderr, "control_socket is not set in %s (use -socket)\n", *configPath)
+			return 2
+		}
+		path = cfg.Settings.ControlSocket
+	}
+
+	client, err := control.NewClient(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	command, commandArgs := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "status":
+		return ctlStatus(client, *asJSON)
+	case "queue":
+		if len(commandArgs) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: ctl queue <monitor>")
+			return 2
+		}
+		return ctlQueue(client, commandArgs[0], *asJSON)
+	case "pause":
+		return ctlEachMonitor(client, commandArgs, client.Pause)
+	case "resume":
+		return ctlEachMonitor(client, commandArgs, client.Resume)
+	case "rescan":
+		return ctlEachMonitor(client, commandArgs, client.Rescan)
+	case "drain":
+		return ctlDrain(client, commandArgs)
+	case "reprocess":
+		return ctlReprocess(client, commandArgs)
+	case "log-level":
+		return ctlLogLevel(client, commandArgs)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", command, ctlUsage)
+		return 2
+	}
+}
+
+// ctlStatus imprime o estado de cada monitor
+func ctlStatus(client *control.Client, asJSON bool) int {
+	statuses, err := client.Monitors()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if asJSON {
+		return printJSON(statuses)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MONITOR\tSTATE\tPAUSED\tQUEUE\tWATCHED\tLAST EVENT\tLAST ERROR")
+	for _, status := range statuses {
+		lastEvent, lastError := "-", "-"
+		if status.LastEvent != nil {
+			lastEvent = ago(*status.LastEvent)
+		}
+		if status.LastError != nil {
+			lastError = fmt.Sprintf("%s: %s", ago(status.LastError.Time), status.LastError.Detail)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%d\t%s\t%s\n",
+			status.Monitor, status.State, status.Paused, status.QueueDepth, status.WatchedDirs, lastEvent, lastError)
+	}
+	w.Flush()
+
+	for _, status := range statuses {
+		for _, problem := range status.Problems {
+			fmt.Printf("%s: %s\n", status.Monitor, problem)
+		}
+	}
+	return 0
+}
+
+// ctlQueue imprime os jobs em processamento e pendentes do monitor
+func ctlQueue(client *control.Client, monitor string, asJSON bool) int {
+	queue, err := client.Queue(monitor)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if asJSON {
+		return printJSON(queue)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATE\tWORKER\tSINCE\tFILE")
+	for _, job := range queue.Running {
+		fmt.Fprintf(w, "running\t%d\t%s\t%s\n", job.Worker, ago(job.StartedAt), job.File)
+	}
+	for _, job := range queue.Pending {
+		fmt.Fprintf(w, "pending\t-\t%s\t%s\n", ago(job.QueuedAt), job.File)
+	}
+	w.Flush()
+
+	fmt.Printf("%d running, %d pending\n", len(queue.Running), len(queue.Pending))
+	return 0
+}
+
+// ctlEachMonitor executa o comando em cada monitor informado (todos, se nenhum)
+func ctlEachMonitor(client *control.Client, monitors []string, command func(monitor string) (string, error)) int {
+	monitors, err := monitorsOrAll(client, monitors)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	exitCode := 0
+	for _, monitor := range monitors {
+		message, err := command(monitor)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Println(message)
+	}
+	return exitCode
+}
+
+// ctlDrain pausa todos os monitores pedidos antes de aguardar cada um esvaziar
+func ctlDrain(client *control.Client, args []string) int {
+	fs := flag.NewFlagSet("drain", flag.ExitOnError)
+	timeout := fs.Duration("timeout", control.DefaultDrainTimeout, "How long to wait for each monitor")
+	fs.Parse(args)
+
+	monitors, err := monitorsOrAll(client, fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Pausar todos primeiro: um monitor não continua recebendo arquivos enquanto outro esvazia
+	for _, monitor := range monitors {
+		if _, err := client.Pause(monitor); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	return ctlEachMonitor(client, monitors, func(monitor string) (string, error) {
+		return client.Drain(monitor, *timeout)
+	})
+}
+
+// ctlReprocess envia os arquivos para processamento novamente
+func ctlReprocess(client *control.Client, files []string) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ctl reprocess <file>...")
+		return 2
+	}
+
+	exitCode := 0
+	for _, file := range files {
+		// O daemon resolve caminhos relativos a partir do próprio diretório de trabalho
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		message, err := client.Reprocess(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Println(message)
+	}
+	return exitCode
+}
+
+// ctlLogLevel mostra ou altera o nível de log
+func ctlLogLevel(client *control.Client, args []string) int {
+	var level string
+	var err error
+
+	switch len(args) {
+	case 0:
+		level, err = client.LogLevel()
+	case 1:
+		level, err = client.SetLogLevel(args[0])
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: ctl log-level [debug|info|warn|error]")
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Println(level)
+	return 0
+}
+
+// monitorsOrAll retorna os monitores informados ou, se nenhum, todos os monitores do daemon
+func monitorsOrAll(client *control.Client, monitors []string) ([]string, error) {
+	if len(monitors) > 0 {
+		return monitors, nil
+	}
+
+	statuses, err := client.Monitors()
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		monitors = append(monitors, status.Monitor)
+	}
+	return monitors, nil
+}
+
+// ago formata há quanto tempo t aconteceu (ex: "3s ago")
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return time.Since(t).Round(time.Second).String() + " ago"
+}
+
+// printJSON imprime value como JSON indentado
+func printJSON(value any) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"gaa/file-organizer/src/processor"
+)
+
+// runDedupe implementa "gaa-organizer dedupe <dir>": procura arquivos idênticos em uma árvore
+// já organizada e, conforme -policy, apenas relata, remove as cópias ou as troca por hardlinks
+func runDedupe(args []string) int {
+	fs := flag.NewFlagSet("dedupe", flag.ExitOnError)
+	policy := fs.String("policy", "report", "What to do with duplicates: report, drop, or hardlink")
+	verbose := fs.Bool("v", false, "Log every file that is deduplicated")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s dedupe [-policy report|drop|hardlink] [-v] <dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := fs.Arg(0)
+
+	switch *policy {
+	case "report":
+		*policy = ""
+	case processor.DedupeDrop, processor.DedupeHardlink:
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid policy: %s (must be report, drop, or hardlink)\n", *policy)
+		return 2
+	}
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	report, err := processor.DedupeTree(dir, *policy, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Dedupe failed: %v\n", err)
+		return 1
+	}
+
+	duplicates := 0
+	for _, group := range report.Groups {
+		fmt.Printf("%s (%d bytes, sha256 %s)\n", group.Original, group.Size, group.SHA256)
+		for _, duplicate := range group.Duplicates {
+			fmt.Printf("  duplicate: %s\n", duplicate)
+			duplicates++
+		}
+	}
+	for _, err := range report.Errors {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+
+	fmt.Printf("%d files scanned, %d duplicate groups, %d duplicates", report.Files, len(report.Groups), duplicates)
+	if *policy != "" {
+		fmt.Printf(", %d bytes freed (%s)", report.BytesFreed, *policy)
+	}
+	fmt.Println()
+
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
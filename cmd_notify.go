@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"gaa/file-organizer/src/audit"
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/notify"
+)
+
+// runNotifyTest implementa "gaa-organizer notify-test [name...]": envia um evento fictício para os
+// notificadores do config (todos, se nenhum nome for informado), ignorando filtros e fila
+// Sai com status 1 se algum envio falhar
+func runNotifyTest(args []string) int {
+	fs := flag.NewFlagSet("notify-test", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to config file")
+	outcome := fs.String("outcome", audit.OutcomeSuccess, "Outcome of the sample event (success, failed, ...)")
+	monitor := fs.String("monitor", "notify-test", "Monitor of the sample event")
+	rule := fs.String("rule", "notify-test", "Rule of the sample event")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s notify-test [-config config.yaml] [-outcome success] [-monitor name] [-rule name] [notifier...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return 2
+	}
+
+	// O resumo em andamento salvo pelo daemon não é do notify-test
+	if digest := cfg.Settings.Notifications.Digest; digest != nil {
+		digest.StateFile = ""
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	notifiers, err := notify.New(&cfg.Settings.Notifications, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range fs.Args() {
+		selected[name] = true
+	}
+
+	event := notify.SampleEvent(*monitor, *rule, *outcome)
+	exitCode, sent := 0, 0
+	for _, notifier := range notifiers {
+		if len(selected) > 0 && !selected[notifier.Name()] {
+			continue
+		}
+		delete(selected, notifier.Name())
+		sent++
+
+		if err := notifier.Send(context.Background(), event); err != nil {
+			fmt.Printf("FAILED %s: %v\n", notifier.Name(), err)
+			exitCode = 1
+			continue
+		}
+		fmt.Printf("OK     %s\n", notifier.Name())
+	}
+
+	for _, notifier := range notifiers {
+		notifier.Close()
+	}
+	for name := range selected {
+		fmt.Fprintf(os.Stderr, "Unknown notifier: %s\n", name)
+		exitCode = 1
+	}
+	if sent == 0 && len(fs.Args()) == 0 {
+		fmt.Fprintln(os.Stderr, "No notifiers configured in settings.notifications")
+		return 2
+	}
+	return exitCode
+}
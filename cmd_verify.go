@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/processor"
+)
+
+// runVerify implementa "gaa-organizer verify [dir...]": recalcula o hash dos arquivos dos destinos
+// e compara com os manifestos. Sem diretórios, verifica os destinos com manifesto do config
+// Sai com status 1 se houver qualquer divergência
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to config file (used when no directory is given)")
+	quiet := fs.Bool("q", false, "Only print discrepancies")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s verify [-config config.yaml] [-q] [dir...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		cfg, err := config.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			return 2
+		}
+		dirs = cfg.ManifestDirs()
+		if len(dirs) == 0 {
+			fmt.Fprintln(os.Stderr, "No rule in the config keeps a manifest; pass the directories to verify")
+			return 2
+		}
+	}
+
+	exitCode := 0
+	for _, dir := range dirs {
+		report, err := processor.VerifyManifest(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", dir, err)
+			exitCode = 1
+			continue
+		}
+
+		for _, rel := range report.Mismatched {
+			fmt.Printf("MISMATCH %s\n", rel)
+		}
+		for _, rel := range report.Missing {
+			fmt.Printf("MISSING  %s\n", rel)
+		}
+		for _, rel := range report.Extra {
+			fmt.Printf("EXTRA    %s\n", rel)
+		}
+		for _, err := range report.Errors {
+			fmt.Printf("ERROR    %v\n", err)
+		}
+
+		status := "OK"
+		if !report.OK() {
+			status = "FAILED"
+			exitCode = 1
+		}
+		if !*quiet || !report.OK() {
+			fmt.Printf("%s %s: %d verified, %d mismatched, %d missing, %d extra\n",
+				status, dir, report.Verified, len(report.Mismatched), len(report.Missing), len(report.Extra))
+		}
+	}
+
+	return exitCode
+}
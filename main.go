@@ -1,17 +1,47 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"io/fs"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"gaa/file-organizer/src/audit"
 	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/control"
+	"gaa/file-organizer/src/instance"
+	"gaa/file-organizer/src/notify"
+	"gaa/file-organizer/src/processor"
+	"gaa/file-organizer/src/server"
+	"gaa/file-organizer/src/systemd"
 	"gaa/file-organizer/src/watcher"
 )
 
+// commands são os subcomandos disponíveis (ex: gaa-organizer dedupe <dir>)
+var commands = map[string]func(args []string) int{
+	"ctl":         runCtl,
+	"dedupe":      runDedupe,
+	"notify-test": runNotifyTest,
+	"verify":      runVerify,
+}
+
 func main() {
+	// Subcomandos
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
 	// Parse CLI flags
 	configPath := flag.String("config", "config.yaml", "Path to config file")
 	flag.Parse()
@@ -27,20 +57,42 @@ func main() {
 		log.Fatalf("Invalid config: %v", err)
 	}
 
+	// Garantir uma única instância por configuração antes de tocar em logs, temporários e monitores
+	lock, err := instance.Acquire(cfg.Settings.LockFile)
+	if err != nil && !instance.Unsupported(err) {
+		log.Fatalf("Failed to acquire instance lock: %v", err)
+	}
+
 	// Inicializar logger
-	logger := config.InitLogger(cfg.Settings.LogLevel)
+	logger := config.InitLogger(cfg.Settings.LogLevel, cfg.Settings.Log)
 	logger.Info("File Organizer Daemon started",
 		"version", "1.0.0",
 		"monitors", len(cfg.Monitors),
 		"max_workers", cfg.Settings.MaxWorkers,
 	)
 
+	if lock == nil {
+		logger.Warn("Single-instance lock not supported on this platform", "error", err)
+	} else {
+		logger.Debug("Instance lock acquired", "path", lock.Path())
+		if cfg.Settings.PIDFile != "" {
+			if err := lock.WritePIDFile(cfg.Settings.PIDFile); err != nil {
+				log.Fatalf("Failed to write pid file: %v", err)
+			}
+		}
+	}
+
 	// Obter delay da configuração
 	delay, err := cfg.ParseDelayDuration()
 	if err != nil {
 		log.Fatalf("Failed to parse delay_before_move: %v", err)
 	}
 
+	shutdownTimeout, err := cfg.ParseShutdownTimeout()
+	if err != nil {
+		log.Fatalf("Failed to parse shutdown_timeout: %v", err)
+	}
+
 	// Obter max_workers da config (com default)
 	maxWorkers := cfg.Settings.MaxWorkers
 	if maxWorkers <= 0 {
@@ -58,10 +110,57 @@ func main() {
 		)
 	}
 
+	// Limpar temporários órfãos de cópias interrompidas (ex: crash durante move entre volumes)
+	if removed := processor.RecoverTempFiles(cfg.Destinations(), logger); removed > 0 {
+		logger.Warn("Removed orphan temporary files from interrupted copies", "count", removed)
+	}
+
+	// Registro de auditoria (um registro JSON por arquivo processado) e notificações de cada job
+	var sinks []audit.Sink
+	if cfg.Settings.Audit.Path != "" {
+		auditFile, err := config.OpenRotatingFile(cfg.Settings.Audit.Path, cfg.Settings.Audit.Rotation)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		sinks = append(sinks, audit.NewLogger(auditFile))
+		logger.Info("Audit log enabled", "path", cfg.Settings.Audit.Path)
+	}
+
+	notifiers, err := notify.New(&cfg.Settings.Notifications, logger)
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+	for _, notifier := range notifiers {
+		sinks = append(sinks, notifier)
+		logger.Info("Notifier enabled", "name", notifier.Name())
+	}
+
+	auditSink := audit.Discard
+	switch len(sinks) {
+	case 0:
+	case 1:
+		auditSink = sinks[0]
+	default:
+		auditSink = audit.Tee(sinks...)
+	}
+
+	// Servidor HTTP de métricas e estado dos monitores (opcional)
+	var httpServer *server.Server
+	if cfg.Settings.HTTPListen != "" {
+		httpServer = server.New(cfg.Settings.HTTPListen, logger)
+		if err := httpServer.Start(); err != nil {
+			log.Fatalf("Failed to start HTTP server: %v", err)
+		}
+	}
+
+	// Contexto do daemon: cancelado por um segundo sinal durante o shutdown (saída imediata)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Inicializar watchers
 	watchers := make([]*watcher.FileWatcher, 0, len(cfg.Monitors))
 	for _, monitor := range cfg.Monitors {
-		w, err := watcher.NewFileWatcher(&monitor, delay, maxWorkers, logger)
+		w, err := watcher.NewFileWatcher(ctx, &monitor, delay, maxWorkers, auditSink, logger)
 		if err != nil {
 			logger.Error("Failed to create watcher", "monitor", monitor.Name, "error", err)
 			continue
@@ -85,6 +184,37 @@ func main() {
 		log.Fatalf("No watchers could be started")
 	}
 
+	if httpServer != nil {
+		httpServer.HandleHealth(watchers)
+	}
+
+	// Reenviar os jobs que não foram concluídos no último shutdown
+	restorePending(cfg.Settings.PendingFile, watchers, logger)
+
+	// Socket de controle (gaa-organizer ctl)
+	var controlServer *control.Server
+	if cfg.Settings.ControlSocket != "" {
+		controlServer = control.New(cfg.Settings.ControlSocket, watchers, logger)
+		if err := controlServer.Start(); err != nil {
+			log.Fatalf("Failed to start control socket: %v", err)
+		}
+	}
+
+	// Verificação periódica de integridade dos destinos com manifesto
+	stopScrub := make(chan struct{})
+	if scrubInterval, _ := cfg.ParseScrubInterval(); scrubInterval > 0 {
+		go runScrub(cfg.ManifestDirs(), scrubInterval, stopScrub, logger)
+	}
+
+	// Avisar o systemd (Type=notify) que o daemon está pronto e manter o watchdog e o STATUS=
+	if err := systemd.Notify(systemd.StateReady); err != nil {
+		logger.Warn("Failed to notify systemd", "error", err)
+	}
+	stopNotify := make(chan struct{})
+	if systemd.Enabled() {
+		go runSystemdNotify(watchers, systemd.WatchdogInterval(), stopNotify, logger)
+	}
+
 	// Graceful shutdown (interceptar Ctrl+C e SIGTERM)
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -93,12 +223,200 @@ func main() {
 
 	// Aguardar sinal de shutdown
 	sig := <-sigChan
-	logger.Info("Shutting down gracefully...", "signal", sig.String())
+	logger.Info("Shutting down gracefully...", "signal", sig.String(), "timeout", shutdownTimeout.String())
+	close(stopNotify)
+	systemd.Notify(systemd.StateStopping)
+
+	// Um segundo sinal encerra sem esperar a fila: os jobs em processamento são abandonados
+	go func() {
+		sig := <-sigChan
+		logger.Warn("Second signal received, forcing shutdown", "signal", sig.String())
+		cancel()
+	}()
+
+	// Parar de aceitar comandos e parar todos os watchers (em paralelo, com o mesmo prazo)
+	if controlServer != nil {
+		controlServer.Stop()
+	}
+	close(stopScrub)
+
+	drainCtx, cancelDrain := context.WithTimeout(ctx, shutdownTimeout)
+	reports := make([]watcher.ShutdownReport, len(watchers))
+	var wg sync.WaitGroup
+	for i, w := range watchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i] = w.Shutdown(drainCtx)
+		}()
+	}
+	wg.Wait()
+	cancelDrain()
+	forced := ctx.Err() != nil
 
-	// Parar todos os watchers
-	for _, w := range watchers {
-		w.Stop()
+	// Resumo do shutdown e jobs salvos para a próxima execução
+	logShutdownSummary(reports, logger)
+	if saved, err := watcher.SavePending(cfg.Settings.PendingFile, reports); err != nil {
+		logger.Error("Failed to save pending jobs", "path", cfg.Settings.PendingFile, "error", err)
+	} else if saved > 0 {
+		logger.Info("Pending jobs saved for next start", "path", cfg.Settings.PendingFile, "jobs", saved)
 	}
 
-	logger.Info("Daemon stopped")
+	if httpServer != nil {
+		httpServer.Stop()
+	}
+
+	if err := auditSink.Close(); err != nil {
+		logger.Error("Failed to close audit log or notifiers", "error", err)
+	}
+
+	if lock != nil {
+		if err := lock.Release(); err != nil {
+			logger.Error("Failed to release instance lock", "error", err)
+		}
+	}
+
+	logger.Info("Daemon stopped", "forced", forced)
+	if forced {
+		os.Exit(1)
+	}
+}
+
+// restorePending reenvia aos monitores os jobs salvos no último shutdown
+// Arquivos que não existem mais (ex: movidos manualmente) são descartados, e moves que já
+// tinham chegado ao destino são concluídos sem reenvio (ver FileWatcher.Restore)
+func restorePending(path string, watchers []*watcher.FileWatcher, logger *slog.Logger) {
+	jobs, err := watcher.LoadPending(path)
+	if err != nil {
+		logger.Error("Failed to load pending jobs", "path", path, "error", err)
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	restored := 0
+	for _, job := range jobs {
+		var target *watcher.FileWatcher
+		for _, w := range watchers {
+			if w.Name() == job.Monitor {
+				target = w
+				break
+			}
+		}
+		if target == nil {
+			logger.Warn("Pending job discarded: monitor not running", "monitor", job.Monitor, "file", job.File)
+			continue
+		}
+
+		if err := target.Restore(job.File); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				logger.Debug("Pending job discarded: file no longer exists", "monitor", job.Monitor, "file", job.File)
+			} else {
+				logger.Warn("Pending job not restored", "monitor", job.Monitor, "file", job.File, "error", err)
+			}
+			continue
+		}
+		restored++
+	}
+
+	logger.Info("Pending jobs from last shutdown restored", "path", path, "restored", restored, "saved", len(jobs))
+}
+
+// logShutdownSummary registra, por monitor, os jobs concluídos, abandonados e pendentes no shutdown
+func logShutdownSummary(reports []watcher.ShutdownReport, logger *slog.Logger) {
+	for _, report := range reports {
+		logger.Info("Shutdown summary",
+			"monitor", report.Monitor,
+			"completed", len(report.Completed),
+			"abandoned", len(report.Abandoned),
+			"persisted", len(report.Pending)+len(report.Abandoned),
+		)
+		if len(report.Completed) > 0 {
+			logger.Debug("Jobs completed during shutdown", "monitor", report.Monitor, "files", report.Completed)
+		}
+		if len(report.Abandoned) > 0 {
+			logger.Warn("Jobs abandoned while in progress", "monitor", report.Monitor, "files", report.Abandoned)
+		}
+		if len(report.Pending) > 0 {
+			logger.Info("Jobs not started before the shutdown deadline", "monitor", report.Monitor, "files", report.Pending)
+		}
+	}
+}
+
+// runScrub verifica os destinos com manifesto a cada intervalo até stopCh ser fechado
+func runScrub(dirs []string, interval time.Duration, stopCh <-chan struct{}, logger *slog.Logger) {
+	logger.Info("Integrity scrub scheduled", "destinations", len(dirs), "interval", interval.String())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if failed := processor.Scrub(dirs, logger); failed > 0 {
+				logger.Error("Integrity scrub found discrepancies", "destinations", failed)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// notifyStatusInterval é o intervalo de atualização do STATUS= quando o watchdog está desativado
+const notifyStatusInterval = 30 * time.Second
+
+// runSystemdNotify atualiza o STATUS= do systemd com os contadores dos monitores e, se o
+// watchdog estiver ativo (WatchdogSec=), envia WATCHDOG=1 enquanto todos os watchers estiverem vivos
+// Um watcher travado deixa de renovar o watchdog e o systemd reinicia o serviço
+func runSystemdNotify(watchers []*watcher.FileWatcher, watchdog time.Duration, stopCh <-chan struct{}, logger *slog.Logger) {
+	interval := notifyStatusInterval
+	if watchdog > 0 {
+		interval = watchdog / 2
+		logger.Info("Systemd watchdog enabled", "timeout", watchdog.String())
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var running, processed, failed, queued int
+		var problems []string
+		for _, w := range watchers {
+			status := w.Status()
+			if status.State == watcher.StateRunning {
+				running++
+			}
+			processed += status.Processed
+			failed += status.Failed
+			queued += status.QueueDepth
+
+			if err := w.Alive(); err != nil {
+				problems = append(problems, w.Name()+": "+err.Error())
+			}
+		}
+
+		text := fmt.Sprintf("Watching %d/%d monitors; %d files processed, %d failed; %d queued",
+			running, len(watchers), processed, failed, queued)
+		if len(problems) > 0 {
+			text += "; " + strings.Join(problems, ", ")
+		}
+		state := "STATUS=" + text
+		if watchdog > 0 {
+			if len(problems) == 0 {
+				state += "\n" + systemd.StateWatchdog
+			} else {
+				logger.Error("Watcher not alive, withholding systemd watchdog ping", "problems", strings.Join(problems, ", "))
+			}
+		}
+		if err := systemd.Notify(state); err != nil {
+			logger.Warn("Failed to notify systemd", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stopCh:
+			return
+		}
+	}
 }
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Resultados possíveis do processamento de um arquivo
+const (
+	OutcomeSuccess   = "success"   // Ação concluída
+	OutcomeSkipped   = "skipped"   // Nada foi feito (arquivo sumiu, conflict_strategy skip, duplicata descartada)
+	OutcomeOverflow  = "overflow"  // Destino cheio: arquivo enviado para o overflow_destination
+	OutcomeFailed    = "failed"    // A ação falhou
+	OutcomeUnmatched = "unmatched" // Nenhuma regra corresponde ao arquivo
+)
+
+// Record é o registro de auditoria de um arquivo processado
+type Record struct {
+	Time         time.Time `json:"time"`
+	Outcome      string    `json:"outcome"`
+	Monitor      string    `json:"monitor"`
+	Rule         string    `json:"rule,omitempty"`
+	Action       string    `json:"action,omitempty"`
+	Source       string    `json:"source"`
+	Destinations []string  `json:"destinations,omitempty"`
+	Size         int64     `json:"size"`
+	SHA256       string    `json:"sha256,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	WorkerID     int       `json:"worker_id"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Sink recebe os registros de auditoria
+type Sink interface {
+	Write(record Record) error
+	Close() error
+}
+
+// Logger grava os registros como JSON, um por linha
+type Logger struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// NewLogger cria um Logger que grava em w
+func NewLogger(w io.WriteCloser) *Logger {
+	return &Logger{w: w}
+}
+
+// Write grava o registro em uma única linha (escritas concorrentes nunca se misturam)
+func (l *Logger) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.w.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close fecha o destino dos registros
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Close()
+}
+
+// discard é o Sink usado quando a auditoria está desativada
+type discard struct{}
+
+func (discard) Write(Record) error { return nil }
+func (discard) Close() error       { return nil }
+
+// Discard descarta todos os registros
+var Discard Sink = discard{}
+
+// tee repassa os registros a vários sinks
+type tee []Sink
+
+// Tee envia cada registro a todos os sinks (ex: arquivo de auditoria e notificações)
+func Tee(sinks ...Sink) Sink {
+	return tee(sinks)
+}
+
+// Write grava o registro em todos os sinks; a falha de um não impede os demais
+func (t tee) Write(record Record) error {
+	var errs []error
+	for _, sink := range t {
+		errs = append(errs, sink.Write(record))
+	}
+	return errors.Join(errs...)
+}
+
+// Close fecha todos os sinks
+func (t tee) Close() error {
+	var errs []error
+	for _, sink := range t {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -2,12 +2,35 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"gaa/file-organizer/src/ignore"
+	"gaa/file-organizer/src/naming"
+
 	"gopkg.in/yaml.v3"
 )
 
+// LockFileSuffix forma o lock de instância única padrão a partir do arquivo de configuração
+// (config.yaml -> config.yaml.lock, no mesmo diretório)
+const LockFileSuffix = ".lock"
+
+// PendingFileSuffix forma o arquivo padrão dos jobs salvos no shutdown a partir do arquivo de configuração
+const PendingFileSuffix = ".pending.json"
+
+// DigestStateSuffix forma o arquivo padrão do resumo diário salvo no shutdown a partir do arquivo de configuração
+const DigestStateSuffix = ".digest.json"
+
+// DefaultShutdownTimeout é o tempo padrão para terminar os jobs pendentes no shutdown
+const DefaultShutdownTimeout = 30 * time.Second
+
 // Config representa a configuração completa do daemon
 type Config struct {
 	Settings Settings  `yaml:"settings"`
@@ -16,28 +39,204 @@ type Config struct {
 
 // Settings contém configurações globais do serviço
 type Settings struct {
-	LogLevel        string `yaml:"log_level"`
-	DelayBeforeMove string `yaml:"delay_before_move"` // Ex: "2s", "500ms"
-	MaxWorkers      int    `yaml:"max_workers"`
+	LogLevel        string        `yaml:"log_level"`
+	Log             Log           `yaml:"log"`               // Opcional: arquivo, formato e rotação do log
+	DelayBeforeMove string        `yaml:"delay_before_move"` // Ex: "2s", "500ms"
+	MaxWorkers      int           `yaml:"max_workers"`
+	ScrubInterval   string        `yaml:"scrub_interval,omitempty"`   // Opcional: verificar periodicamente os destinos com manifesto (ex: "24h")
+	Audit           Audit         `yaml:"audit"`                      // Opcional: registro de auditoria (JSON lines) de cada arquivo processado
+	HTTPListen      string        `yaml:"http_listen,omitempty"`      // Opcional: endereço do servidor HTTP de métricas (ex: "127.0.0.1:9090")
+	ControlSocket   string        `yaml:"control_socket,omitempty"`   // Opcional: socket Unix do controle em execução (gaa-organizer ctl)
+	LockFile        string        `yaml:"lock_file,omitempty"`        // Lock de instância única (padrão: <arquivo de configuração>.lock)
+	PIDFile         string        `yaml:"pid_file,omitempty"`         // Opcional: arquivo com o PID do daemon
+	ShutdownTimeout string        `yaml:"shutdown_timeout,omitempty"` // Tempo para terminar os jobs pendentes no shutdown (padrão 30s)
+	PendingFile     string        `yaml:"pending_file,omitempty"`     // Jobs não concluídos no shutdown (padrão: <arquivo de configuração>.pending.json)
+	Notifications   Notifications `yaml:"notifications"`              // Opcional: webhooks e e-mails após cada job, resumo diário
+}
+
+// Audit configura o registro de auditoria, separado do log de diagnóstico
+type Audit struct {
+	Path     string `yaml:"path"` // Arquivo JSONL dos registros (vazio = auditoria desativada)
+	Rotation `yaml:",inline"`
 }
 
 // Monitor representa uma pasta a ser monitorada
 type Monitor struct {
-	Name       string `yaml:"name"`
-	SourcePath string `yaml:"source_path"`
-	Recursive  bool   `yaml:"recursive"`
-	Rules      []Rule `yaml:"rules"`
+	Name          string   `yaml:"name"`
+	SourcePath    string   `yaml:"source_path"`
+	Recursive     bool     `yaml:"recursive"`
+	WaitForSource bool     `yaml:"wait_for_source"`         // Opcional: aguardar o source_path aparecer (ex: pendrive, montagem de rede) em vez de falhar
+	MaxDepth      int      `yaml:"max_depth"`               // Opcional: profundidade máxima de subpastas monitoradas (0 = sem limite)
+	IncludeDirs   []string `yaml:"include_dirs,omitempty"`  // Opcional: monitorar apenas subpastas que correspondem a esses patterns
+	ExcludeDirs   []string `yaml:"exclude_dirs,omitempty"`  // Opcional: nunca monitorar subpastas que correspondem a esses patterns
+	Ignore        []string `yaml:"ignore,omitempty"`        // Opcional: patterns no formato gitignore (ex: ["*.lnk", "Thumbs.db", "rascunhos/"])
+	IgnoreFiles   bool     `yaml:"ignore_files"`            // Opcional: considerar arquivos .gaaignore encontrados na árvore monitorada
+	TempPatterns  []string `yaml:"temp_patterns,omitempty"` // Opcional: patterns extras de arquivos temporários (ex: ["*.bak", "~*"])
+	Rules         []Rule   `yaml:"rules"`
 }
 
 // Rule representa uma regra de organização de arquivos
 type Rule struct {
-	Name             string   `yaml:"name"`
-	Extensions       []string `yaml:"extensions,omitempty"`          // Opcional: lista de extensões (ex: [".pdf", ".docx"])
-	NameContains     []string `yaml:"name_contains,omitempty"`       // Opcional: arquivo deve conter uma dessas strings no nome (OR logic)
-	NameContainsAll  []string `yaml:"name_contains_all,omitempty"`   // Opcional: arquivo deve conter TODAS essas strings no nome (AND logic)
-	NameStartsWith   []string `yaml:"name_starts_with,omitempty"`    // Opcional: arquivo deve começar com uma dessas strings
-	Destination      string   `yaml:"destination"`
-	ConflictStrategy string   `yaml:"conflict_strategy"` // "rename", "overwrite"
+	Name             string        `yaml:"name"`
+	Extensions       []string      `yaml:"extensions,omitempty"`        // Opcional: lista de extensões (ex: [".pdf", ".docx"])
+	NameContains     []string      `yaml:"name_contains,omitempty"`     // Opcional: arquivo deve conter uma dessas strings no nome (OR logic)
+	NameContainsAll  []string      `yaml:"name_contains_all,omitempty"` // Opcional: arquivo deve conter TODAS essas strings no nome (AND logic)
+	NameStartsWith   []string      `yaml:"name_starts_with,omitempty"`  // Opcional: arquivo deve começar com uma dessas strings
+	Destination      string        `yaml:"destination"`
+	Destinations     []Destination `yaml:"destinations,omitempty"` // Opcional: destinos adicionais (ex: publicar em dois compartilhamentos)
+	Action           string        `yaml:"action,omitempty"`       // Opcional: move (padrão), copy, hardlink, symlink, move_and_link, delete
+	ConflictStrategy string        `yaml:"conflict_strategy"`      // "rename", "overwrite"
+	Preserve         []string      `yaml:"preserve,omitempty"`     // Opcional: metadados preservados em cópias entre volumes (mtime, owner, xattrs, acl)
+
+	Rename      string `yaml:"rename,omitempty"`       // Opcional: template do nome no destino (ex: "{{date \"2006-01\" .ModTime}}_{{slug .Name}}{{.Ext}}")
+	RenameRegex string `yaml:"rename_regex,omitempty"` // Opcional: regex aplicada ao nome (sem extensão); capturas ficam em .Groups e .Captures
+	Sanitize    string `yaml:"sanitize,omitempty"`     // Opcional: adaptar nomes ao destino (posix, windows, ascii)
+
+	Manifest            string `yaml:"manifest,omitempty"`             // Opcional: registrar o SHA-256 de cada arquivo gravado no destino (sha256sums, jsonl)
+	Dedupe              string `yaml:"dedupe,omitempty"`               // Opcional: o que fazer com arquivos idênticos a um já existente no destino (drop, hardlink, keep)
+	MaxSize             string `yaml:"max_size,omitempty"`             // Opcional: tamanho máximo ocupado em cada destino (ex: "50GB")
+	MaxFiles            int    `yaml:"max_files,omitempty"`            // Opcional: quantidade máxima de arquivos em cada destino
+	OverflowDestination string `yaml:"overflow_destination,omitempty"` // Opcional: para onde vão os arquivos quando o destino está cheio
+
+	Pipeline           []Step `yaml:"pipeline,omitempty"`            // Opcional: etapas executadas em ordem no lugar da action
+	FailureDestination string `yaml:"failure_destination,omitempty"` // Opcional: para onde vão os arquivos cujo pipeline falhou
+
+	Monitor string `yaml:"-"` // Nome do monitor da regra, preenchido pelo LoadConfig (label das métricas)
+}
+
+// Destination é um destino adicional da regra: apenas o diretório ("/srv/a") ou um mapa com opções próprias
+type Destination struct {
+	Path     string `yaml:"path"`
+	Sanitize string `yaml:"sanitize,omitempty"` // Opcional: sobrescreve o sanitize da regra neste destino
+}
+
+// UnmarshalYAML aceita o destino como um caminho simples ou como um mapa (path, sanitize)
+func (d *Destination) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&d.Path)
+	}
+	type plain Destination // Sem o método UnmarshalYAML, para não entrar em recursão
+	return node.Decode((*plain)(d))
+}
+
+// Step representa uma etapa do pipeline de uma regra
+type Step struct {
+	Type             string   `yaml:"type"`                        // rename, move, copy, compress, checksum, exec, notify
+	To               string   `yaml:"to,omitempty"`                // rename: template do novo nome (ex: "{{.Rule}}-{{.Name}}{{.Ext}}")
+	Destination      string   `yaml:"destination,omitempty"`       // move/copy: diretório de destino
+	ConflictStrategy string   `yaml:"conflict_strategy,omitempty"` // move/copy/rename/compress: sobrescreve a estratégia da regra
+	Sanitize         string   `yaml:"sanitize,omitempty"`          // move/copy/rename: sobrescreve a política sanitize da regra
+	Format           string   `yaml:"format,omitempty"`            // compress: gzip (padrão) ou zip
+	KeepOriginal     bool     `yaml:"keep_original"`               // compress: manter o arquivo não compactado
+	Command          []string `yaml:"command,omitempty"`           // exec: comando e argumentos (aceitam templates)
+	URL              string   `yaml:"url,omitempty"`               // notify: URL que recebe o POST JSON
+	Timeout          string   `yaml:"timeout,omitempty"`           // exec/notify: tempo máximo (ex: "30s")
+}
+
+// ActionName retorna a ação da regra (move se não definida)
+func (r *Rule) ActionName() string {
+	if r.Action == "" {
+		return "move"
+	}
+	return r.Action
+}
+
+// AllDestinations retorna o destino principal seguido dos destinos adicionais
+func (r *Rule) AllDestinations() []string {
+	destinations := make([]string, 0, 1+len(r.Destinations))
+	if r.Destination != "" {
+		destinations = append(destinations, r.Destination)
+	}
+	for _, destination := range r.Destinations {
+		destinations = append(destinations, destination.Path)
+	}
+	return destinations
+}
+
+// SanitizePolicy retorna a política sanitize dos nomes gravados em destDir:
+// a do destino adicional, se definida, ou a da regra
+func (r *Rule) SanitizePolicy(destDir string) string {
+	for _, destination := range r.Destinations {
+		if destination.Sanitize != "" && filepath.Clean(destination.Path) == filepath.Clean(destDir) {
+			return destination.Sanitize
+		}
+	}
+	return r.Sanitize
+}
+
+// DestinationDirs retorna os diretórios onde a regra organiza arquivos:
+// destinos e destinos das etapas do pipeline
+func (r *Rule) DestinationDirs() []string {
+	dirs := r.AllDestinations()
+	for _, step := range r.Pipeline {
+		if step.Destination != "" {
+			dirs = append(dirs, step.Destination)
+		}
+	}
+	return dirs
+}
+
+// OutputDirs retorna todos os diretórios onde a regra pode gravar arquivos:
+// destinos, destinos das etapas do pipeline, failure_destination e overflow_destination
+func (r *Rule) OutputDirs() []string {
+	dirs := r.DestinationDirs()
+	if r.FailureDestination != "" {
+		dirs = append(dirs, r.FailureDestination)
+	}
+	if r.OverflowDestination != "" {
+		dirs = append(dirs, r.OverflowDestination)
+	}
+	return dirs
+}
+
+// MaxSizeBytes retorna a cota max_size em bytes (0 = sem limite)
+// O valor já foi validado em Validate
+func (r *Rule) MaxSizeBytes() int64 {
+	size, _ := ParseSize(r.MaxSize)
+	return size
+}
+
+// sizeUnits são os sufixos aceitos em tamanhos (potências de 1024)
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize converte tamanhos como "500MB", "10GB" ou "1024" em bytes ("" = 0)
+func ParseSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size '%s' (example: '500MB', '10GB')", size)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// FormatSize formata bytes no formato aceito por ParseSize (ex: "1.5GB", "300KB")
+func FormatSize(size int64) string {
+	for _, unit := range sizeUnits[:4] {
+		if size >= unit.multiplier {
+			return strconv.FormatFloat(float64(size)/float64(unit.multiplier), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
 }
 
 // LoadConfig carrega e parseia o arquivo de configuração YAML
@@ -56,9 +255,63 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Caminhos relativos de log, auditoria, socket de controle, lock, PID file e notificações são relativos
+	// ao diretório do arquivo de configuração (o diretório de trabalho é "/" quando executado pelo systemd)
+	baseDir := filepath.Dir(path)
+	if config.Settings.Log.File == "" {
+		config.Settings.Log.File = DefaultLogFile
+	}
+	config.Settings.Log.File = resolvePath(baseDir, config.Settings.Log.File)
+	if config.Settings.Audit.Path != "" {
+		config.Settings.Audit.Path = resolvePath(baseDir, config.Settings.Audit.Path)
+	}
+	if config.Settings.ControlSocket != "" {
+		config.Settings.ControlSocket = resolvePath(baseDir, config.Settings.ControlSocket)
+	}
+	if config.Settings.LockFile == "" {
+		config.Settings.LockFile = filepath.Base(path) + LockFileSuffix
+	}
+	config.Settings.LockFile = resolvePath(baseDir, config.Settings.LockFile)
+	if config.Settings.PendingFile == "" {
+		config.Settings.PendingFile = filepath.Base(path) + PendingFileSuffix
+	}
+	config.Settings.PendingFile = resolvePath(baseDir, config.Settings.PendingFile)
+	if smtp := &config.Settings.Notifications.SMTP; smtp.PasswordFile != "" {
+		smtp.PasswordFile = resolvePath(baseDir, smtp.PasswordFile)
+	}
+	if digest := config.Settings.Notifications.Digest; digest != nil {
+		if digest.Template != "" {
+			digest.Template = resolvePath(baseDir, digest.Template)
+		}
+		if digest.StateFile == "" {
+			digest.StateFile = filepath.Base(path) + DigestStateSuffix
+		}
+		digest.StateFile = resolvePath(baseDir, digest.StateFile)
+	}
+	if config.Settings.PIDFile != "" {
+		config.Settings.PIDFile = resolvePath(baseDir, config.Settings.PIDFile)
+	}
+
+	for i := range config.Monitors {
+		for j := range config.Monitors[i].Rules {
+			config.Monitors[i].Rules[j].Monitor = config.Monitors[i].Name
+		}
+	}
+
 	return &config, nil
 }
 
+// resolvePath torna path absoluto em relação a baseDir, se for relativo
+func resolvePath(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(filepath.Join(baseDir, path)); err == nil {
+		return abs
+	}
+	return filepath.Join(baseDir, path)
+}
+
 // Validate verifica se a configuração é válida
 func (c *Config) Validate() error {
 	// Validar log level
@@ -72,11 +325,43 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log_level: %s (must be debug, info, warn, or error)", c.Settings.LogLevel)
 	}
 
+	// Validar arquivo de log
+	if err := c.Settings.Log.validate(); err != nil {
+		return fmt.Errorf("invalid log settings: %w", err)
+	}
+
 	// Validar delay_before_move
 	if _, err := c.ParseDelayDuration(); err != nil {
 		return fmt.Errorf("invalid delay_before_move: %w", err)
 	}
 
+	// Validar scrub_interval
+	if _, err := c.ParseScrubInterval(); err != nil {
+		return fmt.Errorf("invalid scrub_interval: %w", err)
+	}
+
+	// Validar shutdown_timeout
+	if _, err := c.ParseShutdownTimeout(); err != nil {
+		return fmt.Errorf("invalid shutdown_timeout: %w", err)
+	}
+
+	// Validar rotação do audit log
+	if err := c.Settings.Audit.validate(); err != nil {
+		return fmt.Errorf("invalid audit settings: %w", err)
+	}
+
+	// Validar notificações
+	if err := c.Settings.Notifications.validate(); err != nil {
+		return fmt.Errorf("invalid notifications: %w", err)
+	}
+
+	// Validar http_listen
+	if c.Settings.HTTPListen != "" {
+		if _, _, err := net.SplitHostPort(c.Settings.HTTPListen); err != nil {
+			return fmt.Errorf("invalid http_listen: %s (example: '127.0.0.1:9090')", c.Settings.HTTPListen)
+		}
+	}
+
 	// Validar max_workers
 	if c.Settings.MaxWorkers <= 0 {
 		return fmt.Errorf("max_workers must be greater than 0, got: %d", c.Settings.MaxWorkers)
@@ -92,11 +377,35 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("monitor #%d has no name", i+1)
 		}
 
-		// Verificar se source_path existe
-		if _, err := os.Stat(monitor.SourcePath); os.IsNotExist(err) {
+		// Verificar se source_path existe (exceto se o monitor aguarda ele aparecer)
+		if _, err := os.Stat(monitor.SourcePath); os.IsNotExist(err) && !monitor.WaitForSource {
 			return fmt.Errorf("monitor '%s': source_path does not exist: %s", monitor.Name, monitor.SourcePath)
 		}
 
+		// Validar limites de recursão
+		if monitor.MaxDepth < 0 {
+			return fmt.Errorf("monitor '%s': max_depth cannot be negative, got: %d", monitor.Name, monitor.MaxDepth)
+		}
+		for _, patterns := range [][]string{monitor.IncludeDirs, monitor.ExcludeDirs} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("monitor '%s': invalid directory pattern '%s': %w", monitor.Name, pattern, err)
+				}
+			}
+		}
+
+		// Validar patterns de arquivos temporários
+		for _, pattern := range monitor.TempPatterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("monitor '%s': invalid temp pattern '%s': %w", monitor.Name, pattern, err)
+			}
+		}
+
+		// Validar patterns de ignore (o erro já cita o pattern)
+		if _, err := ignore.Compile("", monitor.Ignore); err != nil {
+			return fmt.Errorf("monitor '%s': %w", monitor.Name, err)
+		}
+
 		// Validar regras
 		if len(monitor.Rules) == 0 {
 			return fmt.Errorf("monitor '%s' has no rules", monitor.Name)
@@ -112,10 +421,92 @@ func (c *Config) Validate() error {
 				return fmt.Errorf("monitor '%s', rule '%s': must define at least one matching criterion (extensions, name_contains, name_contains_all, or name_starts_with)", monitor.Name, rule.Name)
 			}
 
-			if rule.Destination == "" {
+			// Validar action
+			validActions := map[string]bool{
+				"move":          true,
+				"copy":          true,
+				"hardlink":      true,
+				"symlink":       true,
+				"move_and_link": true,
+				"delete":        true,
+			}
+			if !validActions[rule.ActionName()] {
+				return fmt.Errorf("monitor '%s', rule '%s': invalid action: %s (must be move, copy, hardlink, symlink, move_and_link, or delete)",
+					monitor.Name, rule.Name, rule.Action)
+			}
+
+			// Apenas delete e pipelines dispensam destino
+			if len(rule.Pipeline) > 0 {
+				if rule.Action != "" {
+					return fmt.Errorf("monitor '%s', rule '%s': action and pipeline cannot be used together", monitor.Name, rule.Name)
+				}
+				if len(rule.AllDestinations()) > 0 {
+					return fmt.Errorf("monitor '%s', rule '%s': destination cannot be used with pipeline (use move or copy steps)", monitor.Name, rule.Name)
+				}
+				if err := validatePipeline(rule.Pipeline); err != nil {
+					return fmt.Errorf("monitor '%s', rule '%s': %w", monitor.Name, rule.Name, err)
+				}
+			} else if len(rule.AllDestinations()) == 0 && rule.ActionName() != "delete" {
 				return fmt.Errorf("monitor '%s', rule '%s' has no destination", monitor.Name, rule.Name)
 			}
 
+			// Validar template de renomeação
+			if rule.RenameRegex != "" {
+				if _, err := regexp.Compile(rule.RenameRegex); err != nil {
+					return fmt.Errorf("monitor '%s', rule '%s': invalid rename_regex: %w", monitor.Name, rule.Name, err)
+				}
+				if rule.Rename == "" {
+					return fmt.Errorf("monitor '%s', rule '%s': rename_regex requires rename", monitor.Name, rule.Name)
+				}
+			}
+			if rule.Rename != "" {
+				if len(rule.Pipeline) > 0 {
+					return fmt.Errorf("monitor '%s', rule '%s': rename cannot be used with pipeline (use a rename step)", monitor.Name, rule.Name)
+				}
+				if _, err := naming.Parse(rule.Rename); err != nil {
+					return fmt.Errorf("monitor '%s', rule '%s': invalid rename template: %w", monitor.Name, rule.Name, err)
+				}
+			}
+
+			// Validar formato do manifesto
+			if rule.Manifest != "" && rule.Manifest != "sha256sums" && rule.Manifest != "jsonl" {
+				return fmt.Errorf("monitor '%s', rule '%s': invalid manifest: %s (must be sha256sums or jsonl)", monitor.Name, rule.Name, rule.Manifest)
+			}
+
+			// Validar deduplicação (apenas ações que gravam o conteúdo no destino)
+			if rule.Dedupe != "" {
+				validDedupe := map[string]bool{"drop": true, "hardlink": true, "keep": true}
+				if !validDedupe[rule.Dedupe] {
+					return fmt.Errorf("monitor '%s', rule '%s': invalid dedupe policy: %s (must be drop, hardlink, or keep)",
+						monitor.Name, rule.Name, rule.Dedupe)
+				}
+				action := rule.ActionName()
+				if len(rule.Pipeline) > 0 || (action != "move" && action != "move_and_link" && action != "copy") {
+					return fmt.Errorf("monitor '%s', rule '%s': dedupe requires action move, move_and_link, or copy", monitor.Name, rule.Name)
+				}
+			}
+
+			// Validar cotas por destino
+			if _, err := ParseSize(rule.MaxSize); err != nil {
+				return fmt.Errorf("monitor '%s', rule '%s': invalid max_size: %w", monitor.Name, rule.Name, err)
+			}
+			if rule.MaxFiles < 0 {
+				return fmt.Errorf("monitor '%s', rule '%s': max_files cannot be negative, got: %d", monitor.Name, rule.Name, rule.MaxFiles)
+			}
+
+			// Validar política de sanitização, da regra e de cada destino adicional
+			if err := naming.ValidSanitizePolicy(rule.Sanitize); err != nil {
+				return fmt.Errorf("monitor '%s', rule '%s': %w", monitor.Name, rule.Name, err)
+			}
+			for _, destination := range rule.Destinations {
+				if destination.Path == "" {
+					return fmt.Errorf("monitor '%s', rule '%s': destinations entry without path", monitor.Name, rule.Name)
+				}
+				if err := naming.ValidSanitizePolicy(destination.Sanitize); err != nil {
+					return fmt.Errorf("monitor '%s', rule '%s', destination '%s': %w", monitor.Name, rule.Name, destination.Path, err)
+				}
+			}
+
 			// Validar conflict_strategy
 			validStrategies := map[string]bool{
 				"rename":    true,
@@ -127,17 +518,187 @@ func (c *Config) Validate() error {
 					monitor.Name, rule.Name, rule.ConflictStrategy)
 			}
 
-			// Criar diretório de destino se não existir
-			if err := os.MkdirAll(rule.Destination, 0755); err != nil {
-				return fmt.Errorf("monitor '%s', rule '%s': failed to create destination directory: %w",
-					monitor.Name, rule.Name, err)
+			// Validar metadados a preservar
+			validPreserve := map[string]bool{
+				"mtime":  true,
+				"owner":  true,
+				"xattrs": true,
+				"acl":    true,
+			}
+			for _, option := range rule.Preserve {
+				if !validPreserve[option] {
+					return fmt.Errorf("monitor '%s', rule '%s': invalid preserve option: %s (must be mtime, owner, xattrs, or acl)",
+						monitor.Name, rule.Name, option)
+				}
+			}
+
+			// Criar diretórios de destino se não existirem
+			for _, destination := range rule.OutputDirs() {
+				if err := os.MkdirAll(destination, 0755); err != nil {
+					return fmt.Errorf("monitor '%s', rule '%s': failed to create destination directory: %w",
+						monitor.Name, rule.Name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePipeline verifica os campos obrigatórios de cada etapa do pipeline
+// Etapas que gravam ao lado do arquivo só podem rodar depois que ele saiu da pasta monitorada
+// (um move): arquivos novos ou renomeados na origem seriam processados de novo pela regra
+func validatePipeline(steps []Step) error {
+	validFormats := map[string]bool{"": true, "gzip": true, "zip": true}
+	validStrategies := map[string]bool{"": true, "rename": true, "overwrite": true, "skip": true}
+
+	hasMove := slices.ContainsFunc(steps, func(step Step) bool { return step.Type == "move" })
+	moved := false
+
+	for i, step := range steps {
+		if !validStrategies[step.ConflictStrategy] {
+			return fmt.Errorf("pipeline step %d: invalid conflict_strategy: %s", i+1, step.ConflictStrategy)
+		}
+		if err := naming.ValidSanitizePolicy(step.Sanitize); err != nil {
+			return fmt.Errorf("pipeline step %d: %w", i+1, err)
+		}
+		if step.Timeout != "" {
+			if timeout, err := time.ParseDuration(step.Timeout); err != nil || timeout <= 0 {
+				return fmt.Errorf("pipeline step %d: invalid timeout: %s", i+1, step.Timeout)
+			}
+		}
+
+		switch step.Type {
+		case "rename":
+			if step.To == "" {
+				return fmt.Errorf("pipeline step %d (rename): 'to' is required", i+1)
 			}
+			if _, err := naming.Parse(step.To); err != nil {
+				return fmt.Errorf("pipeline step %d (rename): invalid template: %w", i+1, err)
+			}
+			// Antes do move o rename só escolhe o nome usado pelo move; sem move renomearia na origem
+			if !hasMove {
+				return fmt.Errorf("pipeline step %d (rename): requires a move step (a file renamed inside the watched folder would be processed again)", i+1)
+			}
+		case "move", "copy":
+			if step.Destination == "" {
+				return fmt.Errorf("pipeline step %d (%s): 'destination' is required", i+1, step.Type)
+			}
+		case "compress":
+			if !validFormats[step.Format] {
+				return fmt.Errorf("pipeline step %d (compress): invalid format: %s (must be gzip or zip)", i+1, step.Format)
+			}
+			if !moved {
+				return fmt.Errorf("pipeline step %d (compress): must come after a move step (it writes next to the file, inside the watched folder)", i+1)
+			}
+		case "checksum":
+			if !moved {
+				return fmt.Errorf("pipeline step %d (checksum): must come after a move step (it writes next to the file, inside the watched folder)", i+1)
+			}
+		case "exec":
+			if len(step.Command) == 0 {
+				return fmt.Errorf("pipeline step %d (exec): 'command' is required", i+1)
+			}
+			for _, arg := range step.Command {
+				if _, err := naming.Parse(arg); err != nil {
+					return fmt.Errorf("pipeline step %d (exec): invalid template: %w", i+1, err)
+				}
+			}
+		case "notify":
+			if step.URL == "" {
+				return fmt.Errorf("pipeline step %d (notify): 'url' is required", i+1)
+			}
+		default:
+			return fmt.Errorf("pipeline step %d: invalid type: %s (must be rename, move, copy, compress, checksum, exec, or notify)", i+1, step.Type)
+		}
+
+		if step.Type == "move" {
+			moved = true
 		}
 	}
 
 	return nil
 }
 
+// Destinations retorna todos os diretórios de destino configurados, sem repetições
+func (c *Config) Destinations() []string {
+	seen := make(map[string]bool)
+	var destinations []string
+
+	for _, monitor := range c.Monitors {
+		for _, rule := range monitor.Rules {
+			for _, destination := range rule.OutputDirs() {
+				if seen[destination] {
+					continue
+				}
+				seen[destination] = true
+				destinations = append(destinations, destination)
+			}
+		}
+	}
+
+	return destinations
+}
+
+// ManifestDirs retorna os destinos das regras que mantêm manifesto, sem repetições
+// failure_destination e overflow_destination não têm manifesto e ficam de fora
+func (c *Config) ManifestDirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+
+	for _, monitor := range c.Monitors {
+		for _, rule := range monitor.Rules {
+			if rule.Manifest == "" {
+				continue
+			}
+			for _, dir := range rule.DestinationDirs() {
+				if seen[dir] {
+					continue
+				}
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	return dirs
+}
+
+// ParseScrubInterval converte scrub_interval em time.Duration (0 = scrub desativado)
+func (c *Config) ParseScrubInterval() (time.Duration, error) {
+	if c.Settings.ScrubInterval == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(c.Settings.ScrubInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration format '%s': %w (example: '24h')", c.Settings.ScrubInterval, err)
+	}
+	if interval < time.Minute {
+		return 0, fmt.Errorf("scrub_interval must be at least 1m: %s", c.Settings.ScrubInterval)
+	}
+
+	return interval, nil
+}
+
+// ParseShutdownTimeout converte shutdown_timeout em time.Duration (padrão 30s)
+// 0 encerra sem aguardar a fila: os jobs pendentes são salvos para a próxima execução
+func (c *Config) ParseShutdownTimeout() (time.Duration, error) {
+	if c.Settings.ShutdownTimeout == "" {
+		return DefaultShutdownTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(c.Settings.ShutdownTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration format '%s': %w (example: '30s', '5m')", c.Settings.ShutdownTimeout, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("shutdown_timeout cannot be negative: %s", c.Settings.ShutdownTimeout)
+	}
+
+	return timeout, nil
+}
+
 // ParseDelayDuration converte a string delay_before_move em time.Duration
 func (c *Config) ParseDelayDuration() (time.Duration, error) {
 	duration, err := time.ParseDuration(c.Settings.DelayBeforeMove)
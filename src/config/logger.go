@@ -1,54 +1,198 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
+
+	"gaa/file-organizer/src/systemd"
 )
 
-// InitLogger inicializa o logger com o nível especificado
-// O logger escreve tanto para stdout quanto para o arquivo logs/organizer.log
-func InitLogger(level string) *slog.Logger {
-	// Mapear string para slog.Level
-	var logLevel slog.Level
+// DefaultLogFile é o arquivo de log usado quando settings.log.file não é definido
+// (relativo ao diretório do arquivo de configuração)
+const DefaultLogFile = "logs/organizer.log"
+
+// SyslogIdentifier identifica as mensagens do daemon no journald
+const SyslogIdentifier = "gaa-organizer"
+
+// Log configura o log de diagnóstico
+type Log struct {
+	File     string `yaml:"file"`     // Arquivo de log (padrão logs/organizer.log)
+	Format   string `yaml:"format"`   // text (padrão) ou json
+	Journald bool   `yaml:"journald"` // Enviar ao journald com campos estruturados no lugar do stdout
+	Rotation `yaml:",inline"`
+}
+
+// validate verifica o formato e a rotação do log
+func (l *Log) validate() error {
+	if l.Format != "" && l.Format != "text" && l.Format != "json" {
+		return fmt.Errorf("invalid format: %s (must be text or json)", l.Format)
+	}
+	return l.Rotation.validate()
+}
+
+// reopenFiles são os arquivos reabertos ao receber SIGUSR1 (logrotate externo)
+var (
+	reopenMu    sync.Mutex
+	reopenFiles []*RotatingFile
+)
+
+// registerReopen inclui o arquivo na lista de arquivos reabertos por sinal
+func registerReopen(rf *RotatingFile) {
+	reopenMu.Lock()
+	defer reopenMu.Unlock()
+	reopenFiles = append(reopenFiles, rf)
+}
+
+// ReopenLogFiles reabre todos os arquivos de log abertos (diagnóstico e auditoria)
+func ReopenLogFiles(logger *slog.Logger) {
+	reopenMu.Lock()
+	defer reopenMu.Unlock()
+
+	for _, rf := range reopenFiles {
+		if err := rf.Reopen(); err != nil {
+			logger.Error("Failed to reopen log file", "path", rf.path, "error", err)
+		}
+	}
+	logger.Info("Log files reopened", "files", len(reopenFiles))
+}
+
+// logLevel é o nível atual do logger, alterável em execução (SetLogLevel)
+var logLevel slog.LevelVar
+
+// ParseLogLevel converte o nome do nível (debug, info, warn, error) para slog.Level
+func ParseLogLevel(level string) (slog.Level, error) {
 	switch level {
 	case "debug":
-		logLevel = slog.LevelDebug
+		return slog.LevelDebug, nil
 	case "info":
-		logLevel = slog.LevelInfo
+		return slog.LevelInfo, nil
 	case "warn":
-		logLevel = slog.LevelWarn
+		return slog.LevelWarn, nil
 	case "error":
-		logLevel = slog.LevelError
+		return slog.LevelError, nil
 	default:
-		logLevel = slog.LevelInfo // Default para info
+		return slog.LevelInfo, fmt.Errorf("invalid log level: %s (must be debug, info, warn, or error)", level)
+	}
+}
+
+// SetLogLevel altera o nível do logger criado por InitLogger
+func SetLogLevel(level string) error {
+	parsed, err := ParseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	logLevel.Set(parsed)
+	return nil
+}
+
+// LogLevel retorna o nome do nível atual do logger
+func LogLevel() string {
+	return strings.ToLower(logLevel.Level().String())
+}
+
+// InitLogger inicializa o logger com o nível e as configurações de log especificados
+// O logger escreve tanto para stdout (ou journald) quanto para o arquivo de log, que é
+// rotacionado conforme a configuração e reaberto ao receber SIGUSR1
+func InitLogger(level string, settings Log) *slog.Logger {
+	// Nível inválido usa info (o valor já foi validado em Validate)
+	parsed, _ := ParseLogLevel(level)
+	logLevel.Set(parsed)
+
+	path := settings.File
+	if path == "" {
+		path = DefaultLogFile
 	}
 
-	// Criar diretório de logs se não existir
-	if err := os.MkdirAll("logs", 0755); err != nil {
-		slog.Warn("Failed to create logs directory", "error", err)
+	// journald substitui o stdout (que sob o systemd também iria para o journal, sem os campos)
+	var journal slog.Handler
+	writers := []io.Writer{os.Stdout}
+	if settings.Journald {
+		var err error
+		if journal, err = systemd.NewJournalHandler(SyslogIdentifier, &logLevel); err != nil {
+			slog.Warn("Failed to connect to journald, logging to stdout", "error", err)
+		} else {
+			writers = nil
+		}
 	}
 
-	// Abrir arquivo de log
-	logFile, err := os.OpenFile("logs/organizer.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	// Abrir arquivo de log (o diretório é criado se não existir)
+	logFile, err := OpenRotatingFile(path, settings.Rotation)
 	if err != nil {
-		slog.Warn("Failed to open log file, logging only to stdout", "error", err)
 		// Se falhar, logar apenas para stdout
-		handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-			Level: logLevel,
-		})
-		return slog.New(handler)
+		slog.Warn("Failed to open log file, logging only to stdout", "path", path, "error", err)
+	} else {
+		writers = append(writers, logFile)
 	}
 
 	// Criar MultiWriter para escrever tanto em stdout quanto no arquivo
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
+	writer := io.MultiWriter(writers...)
 
-	// Criar handler com o nível apropriado
-	handler := slog.NewTextHandler(multiWriter, &slog.HandlerOptions{
-		Level: logLevel,
+	// Criar handler com o nível e o formato apropriados
+	options := &slog.HandlerOptions{
+		Level: &logLevel,
 		// Adicionar timestamp e source info para melhor debugging
 		AddSource: false, // Pode ativar se quiser ver arquivo:linha
-	})
+	}
+	var handler slog.Handler
+	if settings.Format == "json" {
+		handler = slog.NewJSONHandler(writer, options)
+	} else {
+		handler = slog.NewTextHandler(writer, options)
+	}
+
+	if journal != nil {
+		handler = fanoutHandler{journal, handler}
+	}
+
+	logger := slog.New(handler)
+	reopenOnSignal(logger)
+	return logger
+}
 
-	return slog.New(handler)
+// fanoutHandler envia cada registro a vários handlers (journald e arquivo de log)
+type fanoutHandler []slog.Handler
+
+// Enabled implementa slog.Handler
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle implementa slog.Handler; um handler com erro não impede os demais
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs implementa slog.Handler
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+// WithGroup implementa slog.Handler
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
 }
@@ -0,0 +1,8 @@
+//go:build windows
+
+package config
+
+import "log/slog"
+
+// reopenOnSignal não tem efeito nesta plataforma (sem SIGUSR1)
+func reopenOnSignal(logger *slog.Logger) {}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package config
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// reopenOnce garante um único handler de SIGUSR1 no processo
+var reopenOnce sync.Once
+
+// reopenOnSignal reabre os arquivos de log ao receber SIGUSR1 (ex: postrotate do logrotate)
+func reopenOnSignal(logger *slog.Logger) {
+	reopenOnce.Do(func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGUSR1)
+
+		go func() {
+			for range sigCh {
+				ReopenLogFiles(logger)
+			}
+		}()
+	})
+}
@@ -0,0 +1,399 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Valores padrão das notificações
+const (
+	DefaultWebhookTimeout = 10 * time.Second
+	DefaultSMTPTimeout    = 30 * time.Second
+	DefaultRetries        = 3
+	DefaultQueueSize      = 100
+	DefaultDigestAt       = "08:00"
+)
+
+// DigestName é o nome do notificador do resumo diário (reservado: webhooks e e-mails não podem usá-lo)
+const DigestName = "digest"
+
+// Modos de segurança da conexão SMTP
+const (
+	SMTPSecurityNone     = "none"     // Texto puro (apenas servidores locais ou de teste)
+	SMTPSecuritySTARTTLS = "starttls" // Conexão normal promovida a TLS com STARTTLS (padrão, porta 587)
+	SMTPSecurityTLS      = "tls"      // TLS desde o início (porta 465)
+)
+
+// outcomes são os resultados de job aceitos nos filtros das notificações (ver audit.Outcome*)
+var outcomes = []string{"success", "skipped", "overflow", "failed", "unmatched"}
+
+// Notifications configura os avisos enviados após cada job
+type Notifications struct {
+	Webhooks []Webhook `yaml:"webhooks"`
+	SMTP     SMTP      `yaml:"smtp"`   // Servidor usado por emails e digest
+	Emails   []Email   `yaml:"emails"` // Um e-mail por job que passa pelo filtro
+	Digest   *Digest   `yaml:"digest"` // Resumo diário por e-mail
+}
+
+// Delivery configura o envio em segundo plano de um notificador
+type Delivery struct {
+	Retries   *int `yaml:"retries,omitempty"`    // Novas tentativas após falha (padrão 3)
+	QueueSize int  `yaml:"queue_size,omitempty"` // Notificações aguardando envio (padrão 100); excedentes são descartadas
+}
+
+// NotifyFilter seleciona os jobs que geram notificação (listas vazias aceitam tudo)
+type NotifyFilter struct {
+	Monitors []string `yaml:"monitors,omitempty"` // Nomes dos monitores
+	Rules    []string `yaml:"rules,omitempty"`    // Nomes das regras (jobs sem regra não passam se definido)
+	Outcomes []string `yaml:"outcomes,omitempty"` // success, skipped, overflow, failed, unmatched
+}
+
+// Webhook é um destino HTTP das notificações
+type Webhook struct {
+	Name         string            `yaml:"name"`
+	URL          string            `yaml:"url"`
+	Method       string            `yaml:"method,omitempty"`  // POST (padrão), PUT...
+	Headers      map[string]string `yaml:"headers,omitempty"` // Ex: Authorization
+	Body         string            `yaml:"body,omitempty"`    // Template do corpo (padrão: o job em JSON)
+	Timeout      string            `yaml:"timeout,omitempty"` // Tempo máximo de cada tentativa (padrão 10s)
+	Delivery     `yaml:",inline"`
+	NotifyFilter `yaml:",inline"`
+}
+
+// SMTP é o servidor de e-mail das notificações
+type SMTP struct {
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port,omitempty"`          // Padrão: 587 (starttls), 465 (tls) ou 25 (none)
+	Security     string `yaml:"security,omitempty"`      // starttls (padrão), tls ou none
+	Username     string `yaml:"username,omitempty"`      // Autenticação PLAIN (exige TLS, exceto em localhost)
+	Password     string `yaml:"password,omitempty"`      // Senha em texto; prefira password_file
+	PasswordFile string `yaml:"password_file,omitempty"` // Arquivo com a senha (ex: credencial do systemd)
+	From         string `yaml:"from"`                    // Remetente (ex: "GAA <gaa@prefeitura.gov.br>")
+	Timeout      string `yaml:"timeout,omitempty"`       // Tempo máximo de cada envio (padrão 30s)
+}
+
+// Email envia uma mensagem para cada job que passa pelo filtro
+type Email struct {
+	Name         string   `yaml:"name"`
+	To           []string `yaml:"to"`
+	Subject      string   `yaml:"subject,omitempty"` // Template do assunto
+	Body         string   `yaml:"body,omitempty"`    // Template do corpo (texto)
+	Delivery     `yaml:",inline"`
+	NotifyFilter `yaml:",inline"`
+}
+
+// Digest envia diariamente um resumo dos jobs do dia por e-mail
+type Digest struct {
+	To        []string `yaml:"to"`
+	At        string   `yaml:"at,omitempty"`         // Horário de envio, hora local (padrão "08:00")
+	Subject   string   `yaml:"subject,omitempty"`    // Template do assunto
+	Template  string   `yaml:"template,omitempty"`   // Arquivo com o template do corpo, relido a cada envio (padrão: embutido)
+	SkipEmpty bool     `yaml:"skip_empty"`           // Não enviar se nenhum job foi processado
+	Monitors  []string `yaml:"monitors,omitempty"`   // Apenas esses monitores (padrão: todos)
+	StateFile string   `yaml:"state_file,omitempty"` // Resumo em andamento salvo no shutdown (padrão: <arquivo de configuração>.digest.json)
+}
+
+// validate verifica os notificadores configurados
+func (n *Notifications) validate() error {
+	names := make(map[string]bool)
+	for i, webhook := range n.Webhooks {
+		if webhook.Name == "" {
+			return fmt.Errorf("webhook #%d has no name", i+1)
+		}
+		if names[webhook.Name] {
+			return fmt.Errorf("duplicate webhook name: %s", webhook.Name)
+		}
+		names[webhook.Name] = true
+
+		if err := webhook.validate(); err != nil {
+			return fmt.Errorf("webhook '%s': %w", webhook.Name, err)
+		}
+	}
+
+	for i, email := range n.Emails {
+		if email.Name == "" {
+			return fmt.Errorf("email #%d has no name", i+1)
+		}
+		if names[email.Name] {
+			return fmt.Errorf("duplicate notifier name: %s", email.Name)
+		}
+		names[email.Name] = true
+
+		if err := email.validate(); err != nil {
+			return fmt.Errorf("email '%s': %w", email.Name, err)
+		}
+	}
+
+	if n.Digest != nil {
+		if names[DigestName] {
+			return fmt.Errorf("notifier name '%s' is reserved for the daily digest", DigestName)
+		}
+		if err := n.Digest.validate(); err != nil {
+			return fmt.Errorf("digest: %w", err)
+		}
+	}
+
+	if len(n.Emails) > 0 || n.Digest != nil {
+		if err := n.SMTP.validate(); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+	}
+	return nil
+}
+
+// validate verifica as novas tentativas e a fila
+func (d *Delivery) validate() error {
+	if d.Retries != nil && *d.Retries < 0 {
+		return fmt.Errorf("retries cannot be negative, got: %d", *d.Retries)
+	}
+	if d.QueueSize < 0 {
+		return fmt.Errorf("queue_size cannot be negative, got: %d", d.QueueSize)
+	}
+	return nil
+}
+
+// RetryCount retorna quantas novas tentativas são feitas após uma falha
+func (d *Delivery) RetryCount() int {
+	if d.Retries == nil {
+		return DefaultRetries
+	}
+	return *d.Retries
+}
+
+// QueueLen retorna o tamanho da fila de envio
+func (d *Delivery) QueueLen() int {
+	if d.QueueSize == 0 {
+		return DefaultQueueSize
+	}
+	return d.QueueSize
+}
+
+// validate verifica um webhook
+func (w *Webhook) validate() error {
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid url: %s (example: 'https://chat.example.com/hooks/abc')", w.URL)
+	}
+	if w.Method != "" && w.Method != strings.ToUpper(w.Method) {
+		return fmt.Errorf("method must be upper case, got: %s", w.Method)
+	}
+	for name := range w.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+	}
+	if w.Body != "" {
+		if _, err := template.New("body").Funcs(TemplateFuncs).Parse(w.Body); err != nil {
+			return fmt.Errorf("invalid body template: %w", err)
+		}
+	}
+	if w.Timeout != "" {
+		if timeout, err := time.ParseDuration(w.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s (example: '10s')", w.Timeout)
+		}
+	}
+	if err := w.Delivery.validate(); err != nil {
+		return err
+	}
+	return w.NotifyFilter.validate()
+}
+
+// validate verifica o servidor SMTP
+func (s *SMTP) validate() error {
+	if s.Host == "" {
+		return fmt.Errorf("'host' is required")
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", s.Port)
+	}
+	switch s.Security {
+	case "", SMTPSecurityNone, SMTPSecuritySTARTTLS, SMTPSecurityTLS:
+	default:
+		return fmt.Errorf("invalid security: %s (must be starttls, tls or none)", s.Security)
+	}
+	if _, err := mail.ParseAddress(s.From); err != nil {
+		return fmt.Errorf("invalid from address '%s': %w", s.From, err)
+	}
+	if s.Username != "" && s.SecurityMode() == SMTPSecurityNone && !isLocalhost(s.Host) {
+		return fmt.Errorf("username requires security starttls or tls (plain text is only allowed for localhost)")
+	}
+	if s.Password != "" && s.PasswordFile != "" {
+		return fmt.Errorf("set either password or password_file, not both")
+	}
+	if s.PasswordFile != "" {
+		if _, err := os.Stat(s.PasswordFile); err != nil {
+			return fmt.Errorf("invalid password_file: %w", err)
+		}
+	}
+	if s.Timeout != "" {
+		if timeout, err := time.ParseDuration(s.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s (example: '30s')", s.Timeout)
+		}
+	}
+	return nil
+}
+
+// SecurityMode retorna o modo de segurança da conexão (starttls se não definido)
+func (s *SMTP) SecurityMode() string {
+	if s.Security == "" {
+		return SMTPSecuritySTARTTLS
+	}
+	return s.Security
+}
+
+// Address retorna host:porta do servidor, com a porta padrão do modo de segurança
+func (s *SMTP) Address() string {
+	port := s.Port
+	if port == 0 {
+		switch s.SecurityMode() {
+		case SMTPSecurityTLS:
+			port = 465
+		case SMTPSecurityNone:
+			port = 25
+		default:
+			port = 587
+		}
+	}
+	return fmt.Sprintf("%s:%d", s.Host, port)
+}
+
+// SMTPTimeout retorna o tempo máximo de cada envio (já validado)
+func (s *SMTP) SMTPTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(s.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return DefaultSMTPTimeout
+}
+
+// LoadPassword retorna a senha do SMTP (do arquivo, se password_file estiver definido)
+func (s *SMTP) LoadPassword() (string, error) {
+	if s.PasswordFile == "" {
+		return s.Password, nil
+	}
+	data, err := os.ReadFile(s.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password_file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// validate verifica um e-mail por job
+func (e *Email) validate() error {
+	if err := validateRecipients(e.To); err != nil {
+		return err
+	}
+	for field, text := range map[string]string{"subject": e.Subject, "body": e.Body} {
+		if _, err := template.New(field).Funcs(TemplateFuncs).Parse(text); err != nil {
+			return fmt.Errorf("invalid %s template: %w", field, err)
+		}
+	}
+	if err := e.Delivery.validate(); err != nil {
+		return err
+	}
+	return e.NotifyFilter.validate()
+}
+
+// validate verifica o resumo diário
+func (d *Digest) validate() error {
+	if err := validateRecipients(d.To); err != nil {
+		return err
+	}
+	if _, err := d.ParseAt(); err != nil {
+		return err
+	}
+	if _, err := template.New("subject").Funcs(TemplateFuncs).Parse(d.Subject); err != nil {
+		return fmt.Errorf("invalid subject template: %w", err)
+	}
+	if d.Template != "" {
+		if _, err := template.New(filepath.Base(d.Template)).Funcs(TemplateFuncs).ParseFiles(d.Template); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	return nil
+}
+
+// ParseAt converte o horário de envio ("HH:MM") em tempo desde a meia-noite
+func (d *Digest) ParseAt() (time.Duration, error) {
+	at := d.At
+	if at == "" {
+		at = DefaultDigestAt
+	}
+	parsed, err := time.Parse("15:04", at)
+	if err != nil {
+		return 0, fmt.Errorf("invalid at: %s (example: '08:00')", d.At)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// isLocalhost indica se host é a própria máquina (aceita autenticação sem TLS)
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// validateRecipients verifica a lista de destinatários
+func validateRecipients(to []string) error {
+	if len(to) == 0 {
+		return fmt.Errorf("'to' is required")
+	}
+	for _, address := range to {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("invalid recipient '%s': %w", address, err)
+		}
+	}
+	return nil
+}
+
+// validate verifica os resultados do filtro
+func (f *NotifyFilter) validate() error {
+	for _, outcome := range f.Outcomes {
+		if !slices.Contains(outcomes, outcome) {
+			return fmt.Errorf("invalid outcome: %s (must be one of %s)", outcome, strings.Join(outcomes, ", "))
+		}
+	}
+	return nil
+}
+
+// Match indica se um job do monitor e da regra com esse resultado passa pelo filtro
+func (f *NotifyFilter) Match(monitor, rule, outcome string) bool {
+	return (len(f.Monitors) == 0 || slices.Contains(f.Monitors, monitor)) &&
+		(len(f.Rules) == 0 || slices.Contains(f.Rules, rule)) &&
+		(len(f.Outcomes) == 0 || slices.Contains(f.Outcomes, outcome))
+}
+
+// WebhookMethod retorna o método HTTP do webhook (POST se não definido)
+func (w *Webhook) WebhookMethod() string {
+	if w.Method == "" {
+		return http.MethodPost
+	}
+	return w.Method
+}
+
+// WebhookTimeout retorna o tempo máximo de cada tentativa (já validado)
+func (w *Webhook) WebhookTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(w.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return DefaultWebhookTimeout
+}
+
+// TemplateFuncs são as funções disponíveis nos templates das notificações
+var TemplateFuncs = template.FuncMap{
+	"json": func(value any) string { // Valor em JSON (strings entre aspas e escapadas)
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "null"
+		}
+		return string(data)
+	},
+	"base": filepath.Base, // Nome do arquivo sem o diretório
+	"size": FormatSize,    // Bytes em formato legível (ex: "1.5GB")
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Rotation define quando um arquivo de log é rotacionado e quantas cópias antigas são mantidas
+type Rotation struct {
+	MaxSize     string `yaml:"max_size,omitempty"`     // Rotacionar ao atingir esse tamanho (ex: "50MB"); vazio = nunca
+	RotateEvery string `yaml:"rotate_every,omitempty"` // Rotacionar quando o arquivo atual tiver esse tempo (ex: "24h"); vazio = nunca
+	MaxBackups  int    `yaml:"max_backups,omitempty"`  // Quantidade de arquivos antigos mantidos (0 = todos)
+	MaxAge      string `yaml:"max_age,omitempty"`      // Remover arquivos antigos mais velhos que isso (ex: "720h")
+	Compress    bool   `yaml:"compress"`               // Compactar os arquivos antigos com gzip
+}
+
+// validate verifica os valores da rotação
+func (r *Rotation) validate() error {
+	if _, err := ParseSize(r.MaxSize); err != nil {
+		return fmt.Errorf("invalid max_size: %w", err)
+	}
+	if r.MaxBackups < 0 {
+		return fmt.Errorf("max_backups cannot be negative, got: %d", r.MaxBackups)
+	}
+	if r.MaxAge != "" {
+		if age, err := time.ParseDuration(r.MaxAge); err != nil || age <= 0 {
+			return fmt.Errorf("invalid max_age: %s (example: '720h')", r.MaxAge)
+		}
+	}
+	if r.RotateEvery != "" {
+		if every, err := time.ParseDuration(r.RotateEvery); err != nil || every < time.Minute {
+			return fmt.Errorf("invalid rotate_every: %s (minimum '1m', example: '24h')", r.RotateEvery)
+		}
+	}
+	return nil
+}
+
+// reopenRetry é o intervalo entre tentativas de reabrir o arquivo enquanto o log vai para o stderr
+const reopenRetry = time.Minute
+
+// RotatingFile é um arquivo de log que se rotaciona sozinho ao atingir o tamanho ou a idade máxima
+// Cópias antigas ficam ao lado como "<nome>.1", "<nome>.2" (ou ".1.gz" com compress)
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	every    time.Duration
+	backups  int
+	maxAge   time.Duration
+	compress bool
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	stderr  bool      // O arquivo não pôde ser reaberto: file é o os.Stderr até a próxima tentativa
+	retryAt time.Time // Próxima tentativa de reabrir o arquivo ou de rotacionar depois de uma falha
+
+	cleanup sync.WaitGroup // Compactação e limpeza dos backups, feitas fora do lock
+}
+
+// OpenRotatingFile abre (ou cria) o arquivo de log com a rotação configurada
+func OpenRotatingFile(path string, rotation Rotation) (*RotatingFile, error) {
+	if err := rotation.validate(); err != nil {
+		return nil, err
+	}
+	maxSize, _ := ParseSize(rotation.MaxSize)
+	maxAge, _ := time.ParseDuration(rotation.MaxAge)
+	every, _ := time.ParseDuration(rotation.RotateEvery)
+
+	rf := &RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		every:    every,
+		backups:  rotation.MaxBackups,
+		maxAge:   maxAge,
+		compress: rotation.Compress,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	registerReopen(rf)
+	return rf, nil
+}
+
+// open abre o arquivo atual em modo append
+// rf.file só é substituído se a abertura der certo; o arquivo anterior fica a cargo de quem chama
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	rf.file = file
+	rf.stderr = false
+	rf.size = info.Size()
+	rf.openedAt = rf.periodStart(info)
+	return nil
+}
+
+// periodStart retorna desde quando o arquivo atual recebe o log, para rotate_every valer entre reinícios
+// O backup mais recente foi gravado pela última vez quando o arquivo atual começou;
+// sem backups, vale a data de modificação do próprio arquivo
+func (rf *RotatingFile) periodStart(info os.FileInfo) time.Time {
+	if info.Size() == 0 {
+		return time.Now()
+	}
+	if backups := rf.listBackups(); len(backups) > 0 {
+		if backup, err := os.Stat(backups[0]); err == nil {
+			return backup.ModTime()
+		}
+	}
+	return info.ModTime()
+}
+
+// useStderr desvia o log para o stderr quando o arquivo não pode ser reaberto
+func (rf *RotatingFile) useStderr(err error) {
+	fmt.Fprintf(os.Stderr, "failed to reopen %s, logging to stderr: %v\n", rf.path, err)
+	rf.file = os.Stderr
+	rf.stderr = true
+	rf.size = 0
+	rf.retryAt = time.Now().Add(reopenRetry)
+}
+
+// Write grava p no arquivo, rotacionando antes se p ultrapassaria o tamanho máximo
+// ou se o arquivo atual já passou de rotate_every
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	n, backup, err := rf.write(p)
+	rf.mu.Unlock()
+
+	// Compactar fora do lock: o log continua sendo gravado enquanto o backup é processado
+	if backup != "" {
+		go rf.cleanBackups(backup)
+	}
+	return n, err
+}
+
+// write grava p com rf.mu travado e retorna o backup criado se houve rotação
+func (rf *RotatingFile) write(p []byte) (int, string, error) {
+	if rf.file == nil {
+		return 0, "", os.ErrClosed
+	}
+
+	if rf.stderr && time.Now().After(rf.retryAt) {
+		if err := rf.open(); err != nil {
+			rf.retryAt = time.Now().Add(reopenRetry)
+		}
+	}
+
+	backup := ""
+	tooBig := rf.maxSize > 0 && rf.size+int64(len(p)) > rf.maxSize
+	tooOld := rf.every > 0 && time.Since(rf.openedAt) >= rf.every
+	if !rf.stderr && rf.size > 0 && (tooBig || tooOld) && time.Now().After(rf.retryAt) {
+		var err error
+		if backup, err = rf.rotate(); err != nil {
+			// Continuar gravando no arquivo atual: perder a rotação é melhor que perder o log
+			fmt.Fprintf(os.Stderr, "failed to rotate %s: %v\n", rf.path, err)
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, backup, err
+}
+
+// Reopen reabre o arquivo (ex: depois que uma ferramenta externa o moveu)
+// O arquivo anterior só é fechado depois que o novo abre; arquivos já fechados com Close não são reabertos
+func (rf *RotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+	old, wasStderr := rf.file, rf.stderr
+	if err := rf.open(); err != nil {
+		return err
+	}
+	if !wasStderr {
+		old.Close()
+	}
+	return nil
+}
+
+// Close fecha o arquivo e espera a compactação de backups em andamento
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	var err error
+	if rf.file != nil && !rf.stderr {
+		err = rf.file.Close()
+	}
+	rf.file = nil
+	rf.mu.Unlock()
+
+	rf.cleanup.Wait()
+	return err
+}
+
+// rotate renomeia o arquivo atual para "<nome>.1" (deslocando as cópias antigas) e abre um novo
+// O arquivo atual continua em uso até o novo abrir
+// Retorna o backup criado, compactado e limpo depois por cleanBackups
+func (rf *RotatingFile) rotate() (string, error) {
+	// A compactação da rotação anterior ainda pode estar usando os nomes dos backups
+	rf.cleanup.Wait()
+
+	// Deslocar backups: .N -> .N+1 (do mais antigo para o mais novo)
+	existing := rf.listBackups()
+	for i := len(existing); i >= 1; i-- {
+		for _, suffix := range []string{"", ".gz"} {
+			from := fmt.Sprintf("%s.%d%s", rf.path, i, suffix)
+			if _, err := os.Stat(from); err == nil {
+				os.Rename(from, fmt.Sprintf("%s.%d%s", rf.path, i+1, suffix))
+			}
+		}
+	}
+
+	backup := rf.path + ".1"
+	old := rf.file
+	if err := os.Rename(rf.path, backup); err != nil && !os.IsNotExist(err) {
+		// Windows não renomeia arquivos abertos: fechar e tentar de novo
+		old.Close()
+		old = nil
+		if err := os.Rename(rf.path, backup); err != nil && !os.IsNotExist(err) {
+			if openErr := rf.open(); openErr != nil {
+				rf.useStderr(openErr)
+			}
+			return "", err
+		}
+	}
+
+	if err := rf.open(); err != nil {
+		if old == nil {
+			rf.useStderr(err)
+			return "", err
+		}
+		// Com o arquivo anterior ainda aberto, devolver o nome e continuar nele até a próxima tentativa
+		os.Rename(backup, rf.path)
+		rf.retryAt = time.Now().Add(reopenRetry)
+		return "", err
+	}
+	if old != nil {
+		old.Close()
+	}
+
+	rf.cleanup.Add(1)
+	return backup, nil
+}
+
+// cleanBackups compacta o backup recém-criado (com compress) e remove os backups excedentes
+// Roda fora do lock; a próxima rotação e Close esperam o término
+func (rf *RotatingFile) cleanBackups(backup string) {
+	defer rf.cleanup.Done()
+
+	if rf.compress {
+		if err := gzipFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to compress %s: %v\n", backup, err)
+		}
+	}
+	rf.prune()
+}
+
+// listBackups retorna os arquivos de backup existentes, do mais novo (.1) para o mais antigo
+func (rf *RotatingFile) listBackups() []string {
+	var backups []string
+	for i := 1; ; i++ {
+		found := ""
+		for _, suffix := range []string{"", ".gz"} {
+			candidate := fmt.Sprintf("%s.%d%s", rf.path, i, suffix)
+			if _, err := os.Stat(candidate); err == nil {
+				found = candidate
+			}
+		}
+		if found == "" {
+			return backups
+		}
+		backups = append(backups, found)
+	}
+}
+
+// prune remove os backups além de max_backups ou mais velhos que max_age
+func (rf *RotatingFile) prune() {
+	for i, backup := range rf.listBackups() {
+		remove := rf.backups > 0 && i >= rf.backups
+		if !remove && rf.maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && time.Since(info.ModTime()) > rf.maxAge {
+				remove = true
+			}
+		}
+		if remove {
+			os.Remove(backup)
+		}
+	}
+}
+
+// gzipFile compacta path para "path.gz" e remove o original
+func gzipFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	dest, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dest)
+	gz.Name = filepath.Base(path)
+	if _, err := io.Copy(gz, source); err != nil {
+		dest.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dest.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dest.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
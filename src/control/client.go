@@ -0,0 +1,163 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"gaa/file-organizer/src/watcher"
+)
+
+// requestTimeout é o tempo máximo de um comando (exceto drain, que usa o próprio timeout)
+const requestTimeout = 30 * time.Second
+
+// Client envia comandos ao daemon pelo socket de controle
+type Client struct {
+	path string
+	http *http.Client
+}
+
+// NewClient cria um cliente para o socket em path
+// O socket precisa pertencer ao usuário atual (ou o usuário atual ser o root): o daemon recusa os demais
+func NewClient(path string) (*Client, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("control socket %s not found (is the daemon running with control_socket set?)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return nil, fmt.Errorf("%s is not a socket", path)
+	}
+	if owner, ok := socketOwner(info); ok && os.Getuid() != 0 && owner != os.Getuid() {
+		return nil, fmt.Errorf("control socket %s is owned by uid %d; run ctl as that user or as root", path, owner)
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+	return &Client{path: path, http: &http.Client{Transport: transport}}, nil
+}
+
+// Monitors retorna o estado de todos os monitores
+func (c *Client) Monitors() ([]watcher.Status, error) {
+	var statuses []watcher.Status
+	err := c.call(http.MethodGet, "/v1/monitors", nil, &statuses, requestTimeout)
+	return statuses, err
+}
+
+// Queue retorna os jobs pendentes e em processamento do monitor
+func (c *Client) Queue(monitor string) (*QueueResponse, error) {
+	var queue QueueResponse
+	err := c.call(http.MethodGet, monitorPath(monitor, "queue"), nil, &queue, requestTimeout)
+	return &queue, err
+}
+
+// Pause pausa o monitor
+func (c *Client) Pause(monitor string) (string, error) {
+	return c.command(http.MethodPost, monitorPath(monitor, "pause"), nil, requestTimeout)
+}
+
+// Resume retoma o monitor
+func (c *Client) Resume(monitor string) (string, error) {
+	return c.command(http.MethodPost, monitorPath(monitor, "resume"), nil, requestTimeout)
+}
+
+// Rescan inicia um rescan imediato do monitor
+func (c *Client) Rescan(monitor string) (string, error) {
+	return c.command(http.MethodPost, monitorPath(monitor, "rescan"), nil, requestTimeout)
+}
+
+// Drain pausa o monitor e aguarda até timeout pela fila vazia
+func (c *Client) Drain(monitor string, timeout time.Duration) (string, error) {
+	path := monitorPath(monitor, "drain") + "?timeout=" + url.QueryEscape(timeout.String())
+	return c.command(http.MethodPost, path, nil, timeout+requestTimeout)
+}
+
+// Reprocess envia um arquivo para processamento novamente
+func (c *Client) Reprocess(path string) (string, error) {
+	return c.command(http.MethodPost, "/v1/reprocess", ReprocessRequest{Path: path}, requestTimeout)
+}
+
+// LogLevel retorna o nível de log atual do daemon
+func (c *Client) LogLevel() (string, error) {
+	var response LogLevelRequest
+	err := c.call(http.MethodGet, "/v1/log-level", nil, &response, requestTimeout)
+	return response.Level, err
+}
+
+// SetLogLevel altera o nível de log do daemon
+func (c *Client) SetLogLevel(level string) (string, error) {
+	var response LogLevelRequest
+	err := c.call(http.MethodPut, "/v1/log-level", LogLevelRequest{Level: level}, &response, requestTimeout)
+	return response.Level, err
+}
+
+// monitorPath monta o caminho de um comando de monitor
+func monitorPath(monitor, command string) string {
+	return "/v1/monitors/" + url.PathEscape(monitor) + "/" + command
+}
+
+// command executa um comando que responde apenas com uma mensagem
+func (c *Client) command(method, path string, body any, timeout time.Duration) (string, error) {
+	var response Response
+	err := c.call(method, path, body, &response, timeout)
+	return response.Message, err
+}
+
+// call envia a requisição e decodifica a resposta em out
+// Respostas de erro ({"error": "..."}) viram o erro retornado
+func (c *Client) call(method, path string, body, out any, timeout time.Duration) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// O host é ignorado: a conexão sempre vai para o socket
+	request, err := http.NewRequestWithContext(ctx, method, "http://gaa-organizer"+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to reach daemon at %s: %w", c.path, err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var failure Response
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			return errors.New(failure.Error)
+		}
+		return fmt.Errorf("daemon returned %s", response.Status)
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,324 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/watcher"
+)
+
+// DefaultDrainTimeout é o tempo máximo de espera do drain quando o cliente não informa outro
+const DefaultDrainTimeout = 5 * time.Minute
+
+// shutdownTimeout é o tempo dado às requisições em andamento ao parar o servidor
+const shutdownTimeout = 5 * time.Second
+
+// Response é a resposta dos comandos que não retornam dados
+type Response struct {
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// QueueResponse é a resposta do comando queue
+type QueueResponse struct {
+	Monitor string            `json:"monitor"`
+	Pending []watcher.JobInfo `json:"pending"`
+	Running []watcher.JobInfo `json:"running"`
+}
+
+// ReprocessRequest é o corpo do comando reprocess
+type ReprocessRequest struct {
+	Path string `json:"path"`
+}
+
+// LogLevelRequest é o corpo (e a resposta) do comando log-level
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// errPeerCredUnsupported indica que a plataforma não informa as credenciais do cliente do socket
+var errPeerCredUnsupported = errors.New("peer credentials not supported on this platform")
+
+// peerKey guarda no contexto da conexão as credenciais do processo cliente
+type peerKey struct{}
+
+// peer são as credenciais do processo cliente de uma conexão
+type peer struct {
+	uid int
+	err error
+}
+
+// Server atende a API de controle (JSON sobre HTTP) em um socket Unix
+// Apenas o usuário dono do daemon e o root podem usar o socket
+type Server struct {
+	path     string
+	watchers []*watcher.FileWatcher
+	http     *http.Server
+	logger   *slog.Logger
+}
+
+// New cria o servidor de controle para os watchers informados
+func New(path string, watchers []*watcher.FileWatcher, logger *slog.Logger) *Server {
+	s := &Server{
+		path:     path,
+		watchers: watchers,
+		logger:   logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/monitors", s.handleMonitors)
+	mux.HandleFunc("GET /v1/monitors/{name}/queue", s.handleQueue)
+	mux.HandleFunc("POST /v1/monitors/{name}/pause", s.handlePause)
+	mux.HandleFunc("POST /v1/monitors/{name}/resume", s.handleResume)
+	mux.HandleFunc("POST /v1/monitors/{name}/rescan", s.handleRescan)
+	mux.HandleFunc("POST /v1/monitors/{name}/drain", s.handleDrain)
+	mux.HandleFunc("POST /v1/reprocess", s.handleReprocess)
+	mux.HandleFunc("GET /v1/log-level", s.handleGetLogLevel)
+	mux.HandleFunc("PUT /v1/log-level", s.handleSetLogLevel)
+
+	s.http = &http.Server{
+		Handler:           s.authorize(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			uid, err := peerUID(conn)
+			return context.WithValue(ctx, peerKey{}, peer{uid: uid, err: err})
+		},
+	}
+	return s
+}
+
+// Start cria o socket (somente para o dono, modo 0600) e passa a atender em segundo plano
+// Um socket abandonado por uma execução anterior é removido; um socket em uso é um erro
+func (s *Server) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+	if err := removeStaleSocket(s.path); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.path, err)
+	}
+	if err := os.Chmod(s.path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set control socket permissions: %w", err)
+	}
+
+	s.logger.Info("Control socket listening", "path", s.path)
+
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Control socket stopped unexpectedly", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Stop encerra o servidor e remove o socket
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.logger.Error("Error stopping control socket", "error", err)
+	}
+	os.Remove(s.path)
+}
+
+// removeStaleSocket remove o socket de uma execução anterior, se ninguém estiver atendendo nele
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("control socket path %s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("control socket %s is in use by another running instance", path)
+	}
+	return os.Remove(path)
+}
+
+// authorize recusa clientes que não sejam o root ou o usuário do daemon
+// Sem suporte a credenciais do cliente na plataforma, vale a permissão 0600 do socket
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, _ := r.Context().Value(peerKey{}).(peer)
+		switch {
+		case errors.Is(client.err, errPeerCredUnsupported):
+		case client.err != nil:
+			s.logger.Warn("Control request rejected: failed to read client credentials", "error", client.err)
+			writeError(w, http.StatusForbidden, errors.New("failed to read client credentials"))
+			return
+		case client.uid != 0 && client.uid != os.Getuid():
+			s.logger.Warn("Control request rejected", "uid", client.uid, "path", r.URL.Path)
+			writeError(w, http.StatusForbidden, fmt.Errorf("uid %d is not allowed to control this daemon", client.uid))
+			return
+		}
+
+		s.logger.Debug("Control request", "method", r.Method, "path", r.URL.Path, "uid", client.uid)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// monitor retorna o watcher do monitor {name} da requisição, respondendo 404 se não existir
+func (s *Server) monitor(w http.ResponseWriter, r *http.Request) *watcher.FileWatcher {
+	name := r.PathValue("name")
+	for _, fw := range s.watchers {
+		if fw.Name() == name {
+			return fw
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("unknown monitor: %s", name))
+	return nil
+}
+
+// handleMonitors lista o estado de todos os monitores
+func (s *Server) handleMonitors(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]watcher.Status, 0, len(s.watchers))
+	for _, fw := range s.watchers {
+		statuses = append(statuses, fw.Status())
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// handleQueue lista os jobs pendentes e em processamento do monitor
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	fw := s.monitor(w, r)
+	if fw == nil {
+		return
+	}
+	pending, running := fw.Jobs()
+	writeJSON(w, http.StatusOK, QueueResponse{Monitor: fw.Name(), Pending: pending, Running: running})
+}
+
+// handlePause pausa o monitor
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if fw := s.monitor(w, r); fw != nil {
+		fw.Pause()
+		writeJSON(w, http.StatusOK, Response{Message: fmt.Sprintf("monitor %s paused", fw.Name())})
+	}
+}
+
+// handleResume retoma o monitor
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if fw := s.monitor(w, r); fw != nil {
+		fw.Resume()
+		writeJSON(w, http.StatusOK, Response{Message: fmt.Sprintf("monitor %s resumed", fw.Name())})
+	}
+}
+
+// handleRescan inicia um rescan imediato do monitor
+func (s *Server) handleRescan(w http.ResponseWriter, r *http.Request) {
+	fw := s.monitor(w, r)
+	if fw == nil {
+		return
+	}
+	if err := fw.Rescan(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Response{Message: fmt.Sprintf("rescan of %s started", fw.Name())})
+}
+
+// handleDrain pausa o monitor e aguarda a fila esvaziar (timeout=<duração> opcional)
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	fw := s.monitor(w, r)
+	if fw == nil {
+		return
+	}
+
+	timeout := DefaultDrainTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid timeout: %s", value))
+			return
+		}
+		timeout = parsed
+	}
+
+	if !fw.Drain(timeout) {
+		writeError(w, http.StatusGatewayTimeout, fmt.Errorf("monitor %s still has jobs after %s (it stays paused)", fw.Name(), timeout))
+		return
+	}
+	writeJSON(w, http.StatusOK, Response{Message: fmt.Sprintf("monitor %s drained and paused", fw.Name())})
+}
+
+// handleReprocess envia um arquivo para o monitor cujo source_path o contém
+func (s *Server) handleReprocess(w http.ResponseWriter, r *http.Request) {
+	var request ReprocessRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Path == "" {
+		writeError(w, http.StatusBadRequest, errors.New("request body must be {\"path\": \"...\"}"))
+		return
+	}
+
+	path, err := filepath.Abs(request.Path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, fw := range s.watchers {
+		if !fw.Contains(path) {
+			continue
+		}
+		if err := fw.Reprocess(path); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, Response{Message: fmt.Sprintf("%s queued on monitor %s", path, fw.Name())})
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("%s is not inside the source_path of any monitor, or is inside a destination", path))
+}
+
+// handleGetLogLevel retorna o nível de log atual
+func (s *Server) handleGetLogLevel(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, LogLevelRequest{Level: config.LogLevel()})
+}
+
+// handleSetLogLevel altera o nível de log em execução
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var request LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("request body must be {\"level\": \"...\"}"))
+		return
+	}
+	if err := config.SetLogLevel(request.Level); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.logger.Info("Log level changed via control socket", "level", request.Level)
+	writeJSON(w, http.StatusOK, LogLevelRequest{Level: config.LogLevel()})
+}
+
+// writeJSON responde com value em JSON
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError responde com o erro em JSON ({"error": "..."})
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Response{Error: err.Error()})
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package control
+
+import "net"
+
+// peerUID não é suportado nesta plataforma: o acesso depende da permissão 0600 do socket
+func peerUID(conn net.Conn) (int, error) {
+	return 0, errPeerCredUnsupported
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package control
+
+import "os"
+
+// socketOwner não é suportado nesta plataforma: a verificação fica a cargo do daemon
+func socketOwner(info os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin
+
+package control
+
+import (
+	"os"
+	"syscall"
+)
+
+// socketOwner retorna o uid do dono do socket
+func socketOwner(info os.FileInfo) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
@@ -0,0 +1,131 @@
+package ignore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern representa uma linha de ignore (formato gitignore) já compilada
+type Pattern struct {
+	Negate bool // Linha "!pattern": re-inclui o que outro pattern ignorou
+
+	base    string // diretório (relativo ao source_path, com "/") onde o pattern foi definido
+	raw     string
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Match verifica se o pattern corresponde ao path relativo ao root (separado por "/")
+func (p Pattern) Match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	// O pattern só vale para paths abaixo do diretório onde foi definido
+	if p.base != "" {
+		if !strings.HasPrefix(rel, p.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, p.base+"/")
+	}
+
+	return p.re.MatchString(rel)
+}
+
+// Compile compila linhas no formato gitignore definidas no diretório base (relativo ao root, "" para o root)
+// Linhas vazias e comentários são ignorados
+func Compile(base string, lines []string) ([]Pattern, error) {
+	patterns := make([]Pattern, 0, len(lines))
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+
+		// Linhas vazias e comentários
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := Pattern{base: base, raw: line}
+
+		// Negação ("!pattern") e escapes ("\!", "\#")
+		if strings.HasPrefix(line, "!") {
+			p.Negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+
+		// Patterns terminados em "/" só correspondem a diretórios
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// Pattern com "/" no início ou no meio é relativo ao diretório base;
+		// caso contrário corresponde ao nome em qualquer nível
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if !anchored && !strings.HasPrefix(line, "**") {
+			line = "**/" + line
+		}
+
+		re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern '%s': %w", p.raw, err)
+		}
+		p.re = re
+
+		patterns = append(patterns, p)
+	}
+
+	return patterns, nil
+}
+
+// globToRegexp converte um glob do gitignore (com suporte a "**") em expressão regular
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			// "**/" corresponde a zero ou mais diretórios
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			// "/**" no final corresponde a tudo dentro do diretório
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,55 @@
+package ignore
+
+import "testing"
+
+func TestCompileMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.tmp", "a.tmp", false, true},
+		{"*.tmp", "sub/dir/a.tmp", false, true},
+		{"*.tmp", "a.tmp.pdf", false, false},
+		{"/raiz.txt", "raiz.txt", false, true},
+		{"/raiz.txt", "sub/raiz.txt", false, false},
+		{"rascunhos/", "rascunhos", true, true},
+		{"rascunhos/", "rascunhos", false, false},
+		{"docs/**/*.bak", "docs/a/b/c.bak", false, true},
+		{"arquivo?.pdf", "arquivo1.pdf", false, true},
+		{"[!a]*.pdf", "b.pdf", false, true},
+		{"[!a]*.pdf", "a.pdf", false, false},
+	}
+	for _, tt := range tests {
+		patterns, err := Compile("", []string{tt.pattern})
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.pattern, err)
+		}
+		if got := patterns[0].Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%q matches %q (dir=%v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestCompileSkipsCommentsAndNegates(t *testing.T) {
+	patterns, err := Compile("sub", []string{"# comentário", "", "*.log", "!importante.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 2 || patterns[0].Negate || !patterns[1].Negate {
+		t.Fatalf("got %+v, want *.log and a negated importante.log", patterns)
+	}
+	// Patterns de um .gaaignore valem apenas abaixo do diretório onde foram definidos
+	if patterns[0].Match("outro/a.log", false) || !patterns[0].Match("sub/a.log", false) {
+		t.Error("pattern defined in sub applied outside of it")
+	}
+}
+
+func TestCompileInvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"[z-a].txt", "[].txt"} {
+		if _, err := Compile("", []string{"*.tmp", pattern}); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", pattern)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package instance
+
+import "os"
+
+// lockFile não é suportado nesta plataforma
+func lockFile(file *os.File) error {
+	return errUnsupported
+}
@@ -0,0 +1,124 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked indica que outra instância do daemon já detém o lock
+var ErrLocked = errors.New("another instance is already running")
+
+// errWouldBlock indica que o lock está com outro processo (retornado por lockFile)
+var errWouldBlock = errors.New("lock held by another process")
+
+// errUnsupported indica que a plataforma não tem flock
+var errUnsupported = errors.New("file locking not supported on this platform")
+
+// Lock é o lock exclusivo de instância única, mantido enquanto o arquivo estiver aberto
+// O kernel libera o lock quando o processo termina, mesmo após um crash ou SIGKILL
+type Lock struct {
+	path    string
+	file    *os.File
+	pidFile string // PID file gravado por WritePIDFile (removido em Release)
+}
+
+// Acquire obtém o lock exclusivo em path (flock) e grava nele o PID do processo
+// Se outra instância detém o lock, retorna ErrLocked com o PID dela
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock file directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, errUnsupported) {
+			return nil, err
+		}
+		if errors.Is(err, errWouldBlock) {
+			if pid := readPID(path); pid > 0 {
+				return nil, fmt.Errorf("%w (pid %d, lock file %s)", ErrLocked, pid, path)
+			}
+			return nil, fmt.Errorf("%w (lock file %s)", ErrLocked, path)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// O conteúdo é só informativo: o lock é o flock, não a existência do arquivo
+	if err := writePID(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write pid to lock file: %w", err)
+	}
+
+	return &Lock{path: path, file: file}, nil
+}
+
+// Unsupported indica se o erro de Acquire é a falta de suporte a flock na plataforma
+func Unsupported(err error) bool {
+	return errors.Is(err, errUnsupported)
+}
+
+// Path retorna o caminho do arquivo de lock
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// WritePIDFile grava o PID do processo em path (substituição atômica)
+// O arquivo é removido por Release
+func (l *Lock) WritePIDFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create pid file directory: %w", err)
+	}
+
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write pid file: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write pid file: %w", err)
+	}
+
+	l.pidFile = path
+	return nil
+}
+
+// Release remove o PID file (se ainda for deste processo) e libera o lock
+// O arquivo de lock não é removido: apagar um arquivo travado permitiria que duas instâncias
+// obtivessem o lock em arquivos diferentes com o mesmo nome
+func (l *Lock) Release() error {
+	if l.pidFile != "" && readPID(l.pidFile) == os.Getpid() {
+		os.Remove(l.pidFile)
+	}
+	return l.file.Close()
+}
+
+// writePID substitui o conteúdo do arquivo de lock pelo PID do processo
+func writePID(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
+
+// readPID lê o PID gravado em path (0 se não for possível)
+func readPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,277 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tipos de métrica do formato texto do Prometheus
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefaultBuckets são os limites (em segundos) usados pelos histogramas de duração
+var DefaultBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// registry guarda todas as métricas criadas, na ordem de criação
+var (
+	registryMu sync.Mutex
+	registry   []*family
+)
+
+// family é uma métrica com todas as suas séries (uma por combinação de labels)
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series guarda o valor de uma combinação de labels
+type series struct {
+	values []string
+	value  float64
+	fn     func() float64 // Gauges calculados no momento da coleta (ex: tamanho da fila)
+
+	// Histogramas
+	counts []uint64 // Contagem por bucket (não acumulada)
+	sum    float64
+	count  uint64
+}
+
+// newFamily cria e registra uma métrica
+func newFamily(name, help, kind string, buckets []float64, labels []string) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+
+	registryMu.Lock()
+	registry = append(registry, f)
+	registryMu.Unlock()
+	return f
+}
+
+// get retorna a série dos valores de label, criando se necessário
+// Deve ser chamado com f.mu travado
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s: expected %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if f.kind == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter é um contador que só cresce
+type Counter struct{ f *family }
+
+// NewCounter cria um contador com os labels informados
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{newFamily(name, help, typeCounter, nil, labels)}
+}
+
+// Inc soma 1 à série dos valores de label
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add soma v (não negativo) à série dos valores de label
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	c.f.mu.Lock()
+	c.f.get(values).value += v
+	c.f.mu.Unlock()
+}
+
+// Gauge é um valor que sobe e desce
+type Gauge struct{ f *family }
+
+// NewGauge cria um gauge com os labels informados
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{newFamily(name, help, typeGauge, nil, labels)}
+}
+
+// Set define o valor da série
+func (g *Gauge) Set(v float64, values ...string) {
+	g.f.mu.Lock()
+	g.f.get(values).value = v
+	g.f.mu.Unlock()
+}
+
+// Add soma v (pode ser negativo) ao valor da série
+func (g *Gauge) Add(v float64, values ...string) {
+	g.f.mu.Lock()
+	g.f.get(values).value += v
+	g.f.mu.Unlock()
+}
+
+// SetFunc faz o valor da série ser calculado por fn a cada coleta
+func (g *Gauge) SetFunc(fn func() float64, values ...string) {
+	g.f.mu.Lock()
+	g.f.get(values).fn = fn
+	g.f.mu.Unlock()
+}
+
+// Delete remove a série (ex: quando o worker pool que ela descreve é parado)
+func (g *Gauge) Delete(values ...string) {
+	g.f.mu.Lock()
+	delete(g.f.series, strings.Join(values, "\xff"))
+	g.f.mu.Unlock()
+}
+
+// Histogram distribui observações em buckets
+type Histogram struct{ f *family }
+
+// NewHistogram cria um histograma com os buckets (limites superiores, em ordem crescente) e labels informados
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{newFamily(name, help, typeHistogram, buckets, labels)}
+}
+
+// Observe registra uma observação na série dos valores de label
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	s := h.f.get(values)
+	for i, bound := range h.f.buckets {
+		if v <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// WriteText grava todas as métricas no formato texto do Prometheus (versão 0.0.4)
+func WriteText(w io.Writer) error {
+	registryMu.Lock()
+	families := append([]*family(nil), registry...)
+	registryMu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// write grava a métrica com as séries em ordem de labels (saída estável entre coletas)
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	all := make([]series, 0, len(keys))
+	for _, key := range keys {
+		s := *f.series[key]
+		s.counts = append([]uint64(nil), s.counts...)
+		all = append(all, s)
+	}
+	f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	for _, s := range all {
+		if f.kind != typeHistogram {
+			value := s.value
+			if s.fn != nil {
+				value = s.fn()
+			}
+			fmt.Fprintf(b, "%s%s %s\n", f.name, formatLabels(f.labels, s.values, "", ""), formatValue(value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.values, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, formatLabels(f.labels, s.values, "", ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, formatLabels(f.labels, s.values, "", ""), s.count)
+	}
+}
+
+// formatLabels monta o bloco {label="valor",...}, com um label extra opcional (le dos histogramas)
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", name, escapeLabel(values[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extraName, extraValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue formata um número como o Prometheus espera (+Inf, -Inf, NaN)
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+// escapeLabel escapa barras, aspas e quebras de linha dos valores de label
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// escapeHelp escapa barras e quebras de linha do texto de ajuda
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// Handler responde com as métricas no formato texto do Prometheus
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteText(w)
+	})
+}
@@ -0,0 +1,45 @@
+package metrics
+
+// Métricas do organizador, expostas em /metrics quando settings.http_listen está configurado
+
+// Eventos do sistema de arquivos
+var (
+	EventsReceived = NewCounter("gaa_events_received_total",
+		"File system events received by the watcher.", "monitor")
+	EventsFiltered = NewCounter("gaa_events_filtered_total",
+		"Events and files discarded before processing, by reason.", "monitor", "reason")
+)
+
+// Fila e workers
+var (
+	JobsQueued = NewCounter("gaa_jobs_queued_total",
+		"Jobs submitted to the worker pool.", "monitor")
+	JobsInFlight = NewGauge("gaa_jobs_in_flight",
+		"Jobs currently being processed by a worker.", "monitor")
+	QueueDepth = NewGauge("gaa_queue_depth",
+		"Jobs waiting in the worker pool queue.", "monitor")
+	QueueWait = NewHistogram("gaa_queue_wait_seconds",
+		"Time a job waited in the queue before a worker picked it up.", DefaultBuckets, "monitor")
+	JobsProcessed = NewCounter("gaa_jobs_processed_total",
+		"Jobs processed, by outcome (success, skipped, overflow, failed, unmatched).", "monitor", "rule", "outcome")
+)
+
+// Regras e ações
+var (
+	RuleHits = NewCounter("gaa_rule_hits_total",
+		"Files matched by each rule.", "monitor", "rule")
+	MoveDuration = NewHistogram("gaa_move_duration_seconds",
+		"Time spent executing the rule action (move, copy, link, pipeline).", DefaultBuckets, "monitor", "rule")
+	BytesCopied = NewCounter("gaa_bytes_copied_total",
+		"Bytes written by copies (copy action, extra destinations and moves across volumes).", "monitor", "rule")
+	Conflicts = NewCounter("gaa_conflicts_total",
+		"Destination files that already existed, by conflict strategy.", "monitor", "rule", "strategy")
+	Failures = NewCounter("gaa_failures_total",
+		"Failed jobs, by error class.", "monitor", "rule", "class")
+)
+
+// Notificações
+var (
+	Notifications = NewCounter("gaa_notifications_total",
+		"Notifications by notifier and result (sent, failed, dropped).", "notifier", "result")
+)
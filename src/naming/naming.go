@@ -0,0 +1,150 @@
+package naming
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// accentMap mapeia letras acentuadas (Latin-1 e Latin Extended-A) para a letra base
+var accentMap = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'Æ': "AE", 'æ': "ae",
+	'Ç': "C", 'Ć': "C", 'Ĉ': "C", 'Ċ': "C", 'Č': "C",
+	'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c",
+	'Ď': "D", 'Đ': "D", 'Ð': "D", 'ď': "d", 'đ': "d", 'ð': "d",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ĕ': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'Ĝ': "G", 'Ğ': "G", 'Ġ': "G", 'Ģ': "G", 'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g",
+	'Ĥ': "H", 'Ħ': "H", 'ĥ': "h", 'ħ': "h",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ĩ': "I", 'Ī': "I", 'Ĭ': "I", 'Į': "I", 'İ': "I",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'Ĵ': "J", 'ĵ': "j", 'Ķ': "K", 'ķ': "k",
+	'Ĺ': "L", 'Ļ': "L", 'Ľ': "L", 'Ŀ': "L", 'Ł': "L", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'Ñ': "N", 'Ń': "N", 'Ņ': "N", 'Ň': "N", 'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ō': "O", 'Ŏ': "O", 'Ő': "O",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'Œ': "OE", 'œ': "oe",
+	'Ŕ': "R", 'Ŗ': "R", 'Ř': "R", 'ŕ': "r", 'ŗ': "r", 'ř': "r",
+	'Ś': "S", 'Ŝ': "S", 'Ş': "S", 'Š': "S", 'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ß': "ss",
+	'Ţ': "T", 'Ť': "T", 'Ŧ': "T", 'ţ': "t", 'ť': "t", 'ŧ': "t", 'Þ': "TH", 'þ': "th",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ũ': "U", 'Ū': "U", 'Ŭ': "U", 'Ů': "U", 'Ű': "U", 'Ų': "U",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'Ŵ': "W", 'ŵ': "w",
+	'Ý': "Y", 'Ŷ': "Y", 'Ÿ': "Y", 'ý': "y", 'ÿ': "y", 'ŷ': "y",
+	'Ź': "Z", 'Ż': "Z", 'Ž': "Z", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Funcs retorna as funções auxiliares disponíveis nos templates de nomes
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"lower":         strings.ToLower,
+		"upper":         strings.ToUpper,
+		"title":         Title,
+		"trim":          strings.TrimSpace,
+		"replace":       replace,
+		"strip_accents": StripAccents,
+		"slug":          Slug,
+		"pad":           Pad,
+		"date":          date,
+	}
+}
+
+// Parse compila um template de nome com as funções auxiliares
+// Campos inexistentes são erro (em vez de gerar "<no value>" no nome do arquivo)
+func Parse(text string) (*template.Template, error) {
+	return template.New("name").Funcs(Funcs()).Option("missingkey=error").Parse(text)
+}
+
+// StripAccents remove acentos das letras (ex: "Relatório São José" -> "Relatorio Sao Jose")
+func StripAccents(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if base, ok := accentMap[r]; ok {
+			b.WriteString(base)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Slug converte o texto em minúsculas sem acentos, com palavras separadas por "-"
+// Exemplo: "RELATORIO receitas (3)" -> "relatorio-receitas-3"
+func Slug(s string) string {
+	var b strings.Builder
+	pendingDash := false
+
+	for _, r := range strings.ToLower(StripAccents(s)) {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingDash = true
+	}
+
+	return b.String()
+}
+
+// Title coloca a primeira letra de cada palavra em maiúscula e o resto em minúsculas
+func Title(s string) string {
+	var b strings.Builder
+	startOfWord := true
+
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if startOfWord {
+				b.WriteRune(unicode.ToUpper(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			startOfWord = false
+			continue
+		}
+		startOfWord = true
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Pad completa o valor com zeros à esquerda até a largura pedida (ex: pad 3 "7" -> "007")
+func Pad(width int, value any) string {
+	s := fmt.Sprint(value)
+	if n := utf8.RuneCountInString(s); n < width {
+		s = strings.Repeat("0", width-n) + s
+	}
+	return s
+}
+
+// replace substitui todas as ocorrências de old por new (ordem de argumentos adequada a pipes)
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// date formata uma data com o layout do Go (ex: date "2006-01" .ModTime)
+func date(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// ValidateComponent verifica se o nome gerado é um único componente de path válido
+func ValidateComponent(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("generated file name is empty")
+	case name == "." || name == "..":
+		return fmt.Errorf("generated file name is not allowed: '%s'", name)
+	case strings.ContainsAny(name, "/\\\x00"):
+		return fmt.Errorf("generated file name must be a single path component: '%s'", name)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package naming
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Políticas de sanitização de nomes de arquivo
+const (
+	SanitizePosix   = "posix"   // Apenas limite de tamanho (255 bytes)
+	SanitizeWindows = "windows" // Caracteres e nomes proibidos no Windows/SMB, pontos e espaços finais
+	SanitizeASCII   = "ascii"   // Regras do Windows e apenas caracteres ASCII
+)
+
+// MaxNameBytes é o tamanho máximo de um nome de arquivo na maioria dos sistemas de arquivos
+const MaxNameBytes = 255
+
+// UniqueSuffixReserve é o espaço reservado para os sufixos de conflito do mover
+// ("_1000" ou "_20060102_150405"), para que o nome sanitizado continue válido após renomeado
+const UniqueSuffixReserve = 16
+
+// replacementChar substitui os caracteres não permitidos
+const replacementChar = "_"
+
+// windowsReservedNames são nomes de dispositivos que o Windows não aceita (com qualquer extensão)
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ValidSanitizePolicy verifica se a política existe ("" desativa a sanitização)
+func ValidSanitizePolicy(policy string) error {
+	switch policy {
+	case "", SanitizePosix, SanitizeWindows, SanitizeASCII:
+		return nil
+	default:
+		return fmt.Errorf("invalid sanitize policy: %s (must be posix, windows, or ascii)", policy)
+	}
+}
+
+// Sanitize adapta o nome do arquivo à política pedida
+// O resultado é determinístico: o mesmo nome sempre gera o mesmo resultado
+func Sanitize(name, policy string) string {
+	if policy == "" {
+		return name
+	}
+
+	if policy == SanitizeASCII {
+		name = StripAccents(name)
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == '/' || r == 0:
+			b.WriteString(replacementChar)
+		case policy != SanitizePosix && (r < 32 || strings.ContainsRune(`<>:"\|?*`, r)):
+			b.WriteString(replacementChar)
+		case policy == SanitizeASCII && r >= utf8.RuneSelf:
+			b.WriteString(replacementChar)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	name = b.String()
+
+	if policy != SanitizePosix {
+		name = trimWindows(name)
+
+		// Nomes reservados (ex: "CON.txt", "nul") ganham um prefixo
+		stem := name
+		if i := strings.IndexByte(stem, '.'); i >= 0 {
+			stem = stem[:i]
+		}
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+			name = replacementChar + name
+		}
+	}
+
+	name = truncateName(name, MaxNameBytes-UniqueSuffixReserve)
+	if policy != SanitizePosix {
+		name = trimWindows(name)
+	}
+
+	if name == "" || name == "." || name == ".." {
+		return replacementChar
+	}
+	return name
+}
+
+// trimWindows remove pontos e espaços finais (o Windows os descarta silenciosamente)
+func trimWindows(name string) string {
+	return strings.TrimRight(name, ". ")
+}
+
+// truncateName limita o nome a maxBytes, preservando a extensão e sem cortar caracteres UTF-8
+func truncateName(name string, maxBytes int) string {
+	if len(name) <= maxBytes {
+		return name
+	}
+
+	ext := ""
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		ext = name[i:]
+	}
+	// Extensões absurdamente longas não são preservadas
+	if len(ext) > maxBytes/2 {
+		ext = ""
+	}
+
+	stem := strings.TrimSuffix(name, ext)
+	limit := maxBytes - len(ext)
+	for limit > 0 && !utf8.RuneStart(stem[limit]) {
+		limit--
+	}
+	return stem[:limit] + ext
+}
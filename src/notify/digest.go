@@ -0,0 +1,400 @@
+package notify
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"gaa/file-organizer/src/audit"
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/metrics"
+)
+
+// maxDigestItems limita as listas de falhas e de arquivos sem regra de um resumo
+const maxDigestItems = 50
+
+// defaultDigestSubject é o assunto do resumo sem subject configurado
+const defaultDigestSubject = `[gaa-organizer] Daily summary: {{.Organized}} organized, {{.Failed}} failed`
+
+// defaultDigestBody é o template do corpo do resumo sem template configurado
+// Serve de ponto de partida para um template próprio (settings.notifications.digest.template)
+//
+//go:embed digest.tmpl
+var defaultDigestBody string
+
+// DigestRule são os totais de uma regra no período
+type DigestRule struct {
+	Monitor   string
+	Rule      string
+	Organized int
+	Skipped   int
+	Failed    int
+	Bytes     int64 // Tamanho dos arquivos organizados
+}
+
+// DigestData é o resumo de um período, disponível no template do resumo diário
+type DigestData struct {
+	From     time.Time
+	To       time.Time
+	Hostname string
+
+	Organized int   // Jobs concluídos (incluindo os enviados para o overflow_destination)
+	Skipped   int   // Jobs em que nada foi feito
+	Failed    int   // Jobs com falha
+	Unmatched int   // Arquivos sem regra correspondente
+	Bytes     int64 // Tamanho dos arquivos organizados
+
+	Rules          []DigestRule // Por monitor e regra, em ordem alfabética
+	FailedFiles    []Event      // Primeiras falhas do período (até maxDigestItems)
+	FailedMore     int          // Falhas que não couberam em FailedFiles
+	UnmatchedFiles []Event      // Primeiros arquivos sem regra (até maxDigestItems)
+	UnmatchedMore  int          // Arquivos sem regra que não couberam em UnmatchedFiles
+
+	rules map[[2]string]*DigestRule // Totais por monitor e regra, ordenados em Rules por finish
+}
+
+// newDigestData inicia o resumo de um período
+func newDigestData(from time.Time, hostname string) *DigestData {
+	return &DigestData{From: from, Hostname: hostname, rules: make(map[[2]string]*DigestRule)}
+}
+
+// Total retorna quantos jobs entraram no resumo
+func (d *DigestData) Total() int {
+	return d.Organized + d.Skipped + d.Failed + d.Unmatched
+}
+
+// add soma o job ao resumo
+func (d *DigestData) add(event Event) {
+	var rule *DigestRule
+	if event.Rule != "" {
+		key := [2]string{event.Monitor, event.Rule}
+		if rule = d.rules[key]; rule == nil {
+			rule = &DigestRule{Monitor: event.Monitor, Rule: event.Rule}
+			d.rules[key] = rule
+		}
+	}
+
+	switch event.Outcome {
+	case audit.OutcomeSuccess, audit.OutcomeOverflow:
+		d.Organized++
+		d.Bytes += event.Size
+		if rule != nil {
+			rule.Organized++
+			rule.Bytes += event.Size
+		}
+	case audit.OutcomeSkipped:
+		d.Skipped++
+		if rule != nil {
+			rule.Skipped++
+		}
+	case audit.OutcomeFailed:
+		d.Failed++
+		if rule != nil {
+			rule.Failed++
+		}
+		if len(d.FailedFiles) < maxDigestItems {
+			d.FailedFiles = append(d.FailedFiles, event)
+		} else {
+			d.FailedMore++
+		}
+	case audit.OutcomeUnmatched:
+		d.Unmatched++
+		if len(d.UnmatchedFiles) < maxDigestItems {
+			d.UnmatchedFiles = append(d.UnmatchedFiles, event)
+		} else {
+			d.UnmatchedMore++
+		}
+	}
+}
+
+// finish fecha o período em to e ordena os totais por monitor e regra
+func (d *DigestData) finish(to time.Time) {
+	d.To = to
+	d.Rules = d.Rules[:0]
+	for _, rule := range d.rules {
+		d.Rules = append(d.Rules, *rule)
+	}
+	slices.SortFunc(d.Rules, func(a, b DigestRule) int {
+		return cmp.Or(cmp.Compare(a.Monitor, b.Monitor), cmp.Compare(a.Rule, b.Rule))
+	})
+}
+
+// saveDigestState grava em path o período ainda não enviado (substituição atômica); sem jobs, remove o arquivo
+func saveDigestState(path string, data *DigestData) error {
+	if data.Total() == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create digest state directory: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, content, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// loadDigestState lê e remove o período salvo no último shutdown
+// Retorna nil se o arquivo não existir
+func loadDigestState(path, hostname string) (*DigestData, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := newDigestData(time.Time{}, hostname)
+	if err := json.Unmarshal(content, data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	// O período continua aberto: os totais por regra voltam para o mapa e Hostname é o atual
+	data.To = time.Time{}
+	data.Hostname = hostname
+	for _, rule := range data.Rules {
+		data.rules[[2]string{rule.Monitor, rule.Rule}] = &rule
+	}
+	data.Rules = nil
+
+	// O período passa a ser do resumo em memória; um novo shutdown grava o arquivo de novo
+	if err := os.Remove(path); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Digest acumula os jobs e envia um resumo por e-mail uma vez por dia, no horário configurado
+// O resumo em andamento é salvo em state_file no shutdown e retomado na próxima execução
+type Digest struct {
+	config   *config.Digest
+	mailer   *mailer
+	subject  *template.Template
+	hour     int
+	minute   int
+	hostname string
+	logger   *slog.Logger
+
+	mu     sync.Mutex // protege period
+	period *DigestData
+
+	ctx    context.Context // cancelado por Close
+	cancel context.CancelFunc
+	done   chan struct{} // fechado quando o agendamento termina
+}
+
+// NewDigest cria o resumo diário e inicia o agendamento do envio
+func NewDigest(cfg *config.Digest, mailer *mailer, logger *slog.Logger) (*Digest, error) {
+	at, err := cfg.ParseAt()
+	if err != nil {
+		return nil, err
+	}
+	subject, err := parseTemplate(config.DigestName, cfg.Subject, defaultDigestSubject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	hostname, _ := os.Hostname()
+
+	period := newDigestData(time.Now(), hostname)
+	if cfg.StateFile != "" {
+		saved, err := loadDigestState(cfg.StateFile, hostname)
+		if err != nil {
+			logger.Error("Failed to load saved digest, starting a new summary", "path", cfg.StateFile, "error", err)
+		} else if saved != nil {
+			logger.Info("Digest summary restored", "since", saved.From.Format(time.DateTime), "files", saved.Total())
+			period = saved
+		}
+	}
+
+	d := &Digest{
+		config:   cfg,
+		mailer:   mailer,
+		subject:  subject,
+		hour:     int(at / time.Hour),
+		minute:   int(at % time.Hour / time.Minute),
+		hostname: hostname,
+		logger:   logger,
+		period:   period,
+		done:     make(chan struct{}),
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	go d.run()
+	return d, nil
+}
+
+// Name retorna o nome do notificador
+func (d *Digest) Name() string {
+	return config.DigestName
+}
+
+// Write soma o job ao resumo do período (apenas dos monitores configurados)
+func (d *Digest) Write(record audit.Record) error {
+	if len(d.config.Monitors) > 0 && !slices.Contains(d.config.Monitors, record.Monitor) {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.period.add(NewEvent(record))
+	return nil
+}
+
+// Close para o agendamento e salva em state_file o resumo ainda não enviado
+// Sem state_file (comando notify-test) o resumo é descartado
+func (d *Digest) Close() error {
+	d.cancel()
+	<-d.done
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	total := d.period.Total()
+	if d.config.StateFile == "" {
+		if total > 0 {
+			d.logger.Info("Digest not sent before shutdown, discarding summary",
+				"since", d.period.From.Format(time.DateTime),
+				"files", total,
+			)
+		}
+		return nil
+	}
+
+	d.period.finish(time.Now())
+	if err := saveDigestState(d.config.StateFile, d.period); err != nil {
+		return fmt.Errorf("failed to save digest summary: %w", err)
+	}
+	if total > 0 {
+		d.logger.Info("Digest not sent before shutdown, summary saved",
+			"path", d.config.StateFile,
+			"since", d.period.From.Format(time.DateTime),
+			"files", total,
+		)
+	}
+	return nil
+}
+
+// Send envia agora um resumo contendo apenas o evento (comando notify-test)
+func (d *Digest) Send(ctx context.Context, event Event) error {
+	data := newDigestData(event.Time, d.hostname)
+	data.add(event)
+	data.finish(time.Now())
+	return d.send(ctx, data)
+}
+
+// run envia o resumo todo dia no horário configurado até Close
+func (d *Digest) run() {
+	defer close(d.done)
+
+	// Resumo restaurado cujo horário de envio passou com o daemon parado: enviar agora
+	d.mu.Lock()
+	from := d.period.From
+	d.mu.Unlock()
+	if !nextRun(from, d.hour, d.minute).After(time.Now()) {
+		d.flush()
+	}
+
+	var last time.Time
+	for {
+		// Nunca repetir o mesmo horário, mesmo se o relógio voltar um pouco
+		now := time.Now()
+		if now.Before(last) {
+			now = last
+		}
+		next := nextRun(now, d.hour, d.minute)
+		d.logger.Debug("Next digest scheduled", "at", next.Format(time.DateTime))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-d.ctx.Done():
+			timer.Stop()
+			return
+		}
+		last = next
+		d.flush()
+	}
+}
+
+// flush fecha o período atual, inicia um novo e envia o resumo do período fechado
+func (d *Digest) flush() {
+	now := time.Now()
+	d.mu.Lock()
+	data := d.period
+	d.period = newDigestData(now, d.hostname)
+	d.mu.Unlock()
+	data.finish(now)
+
+	if d.config.SkipEmpty && data.Total() == 0 {
+		d.logger.Info("Digest skipped, no files processed")
+		return
+	}
+
+	if err := d.send(d.ctx, data); err != nil {
+		metrics.Notifications.Inc(config.DigestName, "failed")
+		d.logger.Error("Digest delivery failed", "files", data.Total(), "error", err)
+		return
+	}
+	metrics.Notifications.Inc(config.DigestName, "sent")
+	d.logger.Info("Digest sent", "files", data.Total(), "failed", data.Failed, "unmatched", data.Unmatched)
+}
+
+// send renderiza e envia o resumo, com novas tentativas para erros de rede e respostas 4xx
+func (d *Digest) send(ctx context.Context, data *DigestData) error {
+	subject, err := render(d.subject, data)
+	if err != nil {
+		return fmt.Errorf("failed to render subject template: %w", err)
+	}
+	body, err := render(d.body(), data)
+	if err != nil {
+		return fmt.Errorf("failed to render digest template: %w", err)
+	}
+	return withRetries(ctx, config.DigestName, config.DefaultRetries, d.logger, func() (bool, error) {
+		return d.mailer.send(ctx, d.config.To, subject, body)
+	})
+}
+
+// body carrega o template do corpo a cada envio, para que edições valham sem reiniciar
+// Com o arquivo inválido ou ausente usa o template embutido, para o resumo não deixar de ser enviado
+func (d *Digest) body() *template.Template {
+	fallback := template.Must(parseTemplate(config.DigestName, "", defaultDigestBody))
+	if d.config.Template == "" {
+		return fallback
+	}
+
+	body, err := template.New(filepath.Base(d.config.Template)).Funcs(config.TemplateFuncs).ParseFiles(d.config.Template)
+	if err != nil {
+		d.logger.Error("Failed to load digest template, using default", "path", d.config.Template, "error", err)
+		return fallback
+	}
+	return body
+}
+
+// nextRun retorna o próximo horário hour:minute (hora local) estritamente depois de now
+func nextRun(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return next
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"gaa/file-organizer/src/audit"
+	"gaa/file-organizer/src/config"
+)
+
+func TestNextRun(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"before the time", time.Date(2026, 3, 14, 7, 59, 59, 0, time.Local), time.Date(2026, 3, 14, 8, 0, 0, 0, time.Local)},
+		{"exactly at the time", time.Date(2026, 3, 14, 8, 0, 0, 0, time.Local), time.Date(2026, 3, 15, 8, 0, 0, 0, time.Local)},
+		{"after the time", time.Date(2026, 3, 14, 8, 0, 1, 0, time.Local), time.Date(2026, 3, 15, 8, 0, 0, 0, time.Local)},
+		{"end of month", time.Date(2026, 4, 30, 23, 0, 0, 0, time.Local), time.Date(2026, 5, 1, 8, 0, 0, 0, time.Local)},
+		{"end of year", time.Date(2026, 12, 31, 9, 0, 0, 0, time.Local), time.Date(2027, 1, 1, 8, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRun(tt.now, 8, 0); !got.Equal(tt.want) {
+				t.Errorf("nextRun(%s) = %s, want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestDataAddFinish(t *testing.T) {
+	from := time.Date(2026, 3, 14, 8, 0, 0, 0, time.UTC)
+	data := newDigestData(from, "host")
+
+	data.add(NewEvent(testRecord("scans", "pdf", audit.OutcomeSuccess, "/in/a.pdf")))
+	data.add(NewEvent(testRecord("docs", "pdf", audit.OutcomeSuccess, "/in/b.pdf")))
+	data.add(NewEvent(testRecord("docs", "pdf", audit.OutcomeOverflow, "/in/c.pdf")))
+	data.add(NewEvent(testRecord("docs", "images", audit.OutcomeSkipped, "/in/d.jpg")))
+	for i := range maxDigestItems + 3 {
+		data.add(NewEvent(testRecord("docs", "images", audit.OutcomeFailed, fmt.Sprintf("/in/%d.jpg", i))))
+	}
+	data.add(NewEvent(testRecord("docs", "", audit.OutcomeUnmatched, "/in/e.txt")))
+
+	to := from.Add(24 * time.Hour)
+	data.finish(to)
+
+	if data.Organized != 3 || data.Skipped != 1 || data.Failed != maxDigestItems+3 || data.Unmatched != 1 {
+		t.Errorf("organized=%d skipped=%d failed=%d unmatched=%d, want 3, 1, %d, 1",
+			data.Organized, data.Skipped, data.Failed, data.Unmatched, maxDigestItems+3)
+	}
+	if data.Total() != 3+1+maxDigestItems+3+1 {
+		t.Errorf("Total() = %d", data.Total())
+	}
+	if data.Bytes != 3*2048 {
+		t.Errorf("Bytes = %d, want %d", data.Bytes, 3*2048)
+	}
+	if len(data.FailedFiles) != maxDigestItems || data.FailedMore != 3 {
+		t.Errorf("FailedFiles = %d, FailedMore = %d, want %d and 3", len(data.FailedFiles), data.FailedMore, maxDigestItems)
+	}
+	if len(data.UnmatchedFiles) != 1 || data.UnmatchedFiles[0].Filename != "e.txt" || data.UnmatchedMore != 0 {
+		t.Errorf("UnmatchedFiles = %+v, UnmatchedMore = %d", data.UnmatchedFiles, data.UnmatchedMore)
+	}
+	if !data.To.Equal(to) {
+		t.Errorf("To = %s, want %s", data.To, to)
+	}
+
+	want := []DigestRule{
+		{Monitor: "docs", Rule: "images", Skipped: 1, Failed: maxDigestItems + 3},
+		{Monitor: "docs", Rule: "pdf", Organized: 2, Bytes: 2 * 2048},
+		{Monitor: "scans", Rule: "pdf", Organized: 1, Bytes: 2048},
+	}
+	if !slices.Equal(data.Rules, want) {
+		t.Errorf("Rules = %+v, want %+v", data.Rules, want)
+	}
+
+	// finish pode ser chamado de novo sem duplicar as regras
+	data.finish(to)
+	if len(data.Rules) != len(want) {
+		t.Errorf("got %d rules after second finish, want %d", len(data.Rules), len(want))
+	}
+}
+
+func TestDigestStateSurvivesRestart(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "config.yaml"+config.DigestStateSuffix)
+	mailer, err := newMailer(&config.SMTP{Host: "127.0.0.1", Security: config.SMTPSecurityNone, From: "gaa@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Digest{To: []string{"admin@example.com"}, At: "08:00", StateFile: stateFile}
+
+	digest, err := NewDigest(cfg, mailer, testLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := digest.period.From
+	digest.Write(testRecord("docs", "pdf", audit.OutcomeSuccess, "/in/a.pdf"))
+	digest.Write(testRecord("docs", "pdf", audit.OutcomeFailed, "/in/b.pdf"))
+	if err := digest.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		t.Fatalf("digest state not saved: %v", err)
+	}
+
+	digest, err = NewDigest(cfg, mailer, testLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Errorf("digest state not removed after loading: %v", err)
+	}
+	digest.Write(testRecord("docs", "pdf", audit.OutcomeSuccess, "/in/c.pdf"))
+
+	digest.mu.Lock()
+	data := digest.period
+	data.finish(time.Now())
+	if !data.From.Equal(from) || data.Organized != 2 || data.Failed != 1 || len(data.FailedFiles) != 1 {
+		t.Errorf("restored period: from=%s organized=%d failed=%d failedFiles=%d, want from=%s, 2, 1, 1",
+			data.From, data.Organized, data.Failed, len(data.FailedFiles), from)
+	}
+	if want := []DigestRule{{Monitor: "docs", Rule: "pdf", Organized: 2, Failed: 1, Bytes: 2 * 2048}}; !slices.Equal(data.Rules, want) {
+		t.Errorf("Rules = %+v, want %+v", data.Rules, want)
+	}
+	digest.mu.Unlock()
+
+	// Sem jobs no período, o shutdown não deixa arquivo
+	digest.mu.Lock()
+	digest.period = newDigestData(time.Now(), "host")
+	digest.mu.Unlock()
+	if err := digest.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Errorf("empty digest state saved: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gaa/file-organizer/src/audit"
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/metrics"
+)
+
+// retryBaseDelay é a espera antes da primeira nova tentativa (dobra a cada tentativa)
+// Variável para os testes poderem encurtá-la
+var retryBaseDelay = time.Second
+
+// maxRetryDelay limita a espera entre tentativas
+const maxRetryDelay = 30 * time.Second
+
+// closeTimeout é quanto Close aguarda a fila de envio esvaziar antes de descartar o resto
+const closeTimeout = 10 * time.Second
+
+// dispatcher entrega em segundo plano as notificações de um notificador (webhook, e-mail)
+// Implementa audit.Sink: Write só filtra e enfileira, nunca bloqueia o worker que processou o arquivo
+type dispatcher struct {
+	name   string
+	filter *config.NotifyFilter
+	send   func(ctx context.Context, event Event) error
+	logger *slog.Logger
+
+	mu     sync.Mutex // protege closed e o fechamento da fila
+	closed bool
+	queue  chan Event
+	ctx    context.Context // cancelado quando Close desiste de esperar a fila
+	cancel context.CancelFunc
+	done   chan struct{} // fechado quando o envio em segundo plano termina
+}
+
+// newDispatcher cria o dispatcher e inicia o envio em segundo plano
+func newDispatcher(name string, filter *config.NotifyFilter, queueSize int, send func(ctx context.Context, event Event) error, logger *slog.Logger) *dispatcher {
+	d := &dispatcher{
+		name:   name,
+		filter: filter,
+		send:   send,
+		logger: logger,
+		queue:  make(chan Event, queueSize),
+		done:   make(chan struct{}),
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	go d.run()
+	return d
+}
+
+// Name retorna o nome do notificador
+func (d *dispatcher) Name() string {
+	return d.name
+}
+
+// Write enfileira a notificação do job, se ele passar pelo filtro
+// Com a fila cheia a notificação é descartada: o processamento dos arquivos nunca espera o envio
+func (d *dispatcher) Write(record audit.Record) error {
+	if !d.filter.Match(record.Monitor, record.Rule, record.Outcome) {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+
+	select {
+	case d.queue <- NewEvent(record):
+	default:
+		metrics.Notifications.Inc(d.name, "dropped")
+		d.logger.Warn("Notification queue full, notification dropped", "notifier", d.name, "file", record.Source)
+	}
+	return nil
+}
+
+// Close para de aceitar notificações e aguarda o envio das que estão na fila (até closeTimeout)
+func (d *dispatcher) Close() error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+	case <-time.After(closeTimeout):
+		d.logger.Warn("Notification queue not flushed in time, dropping remaining notifications",
+			"notifier", d.name, "pending", len(d.queue))
+		d.cancel()
+		<-d.done
+	}
+	d.cancel()
+	return nil
+}
+
+// run envia as notificações da fila até ela ser fechada
+func (d *dispatcher) run() {
+	defer close(d.done)
+
+	for event := range d.queue {
+		if d.ctx.Err() != nil {
+			metrics.Notifications.Inc(d.name, "dropped")
+			continue
+		}
+
+		if err := d.send(d.ctx, event); err != nil {
+			metrics.Notifications.Inc(d.name, "failed")
+			d.logger.Error("Notification delivery failed", "notifier", d.name, "file", event.Source, "error", err)
+			continue
+		}
+		metrics.Notifications.Inc(d.name, "sent")
+		d.logger.Debug("Notification delivered", "notifier", d.name, "file", event.Source)
+	}
+}
+
+// withRetries executa attempt até ele funcionar, retornar um erro definitivo ou esgotar as novas
+// tentativas, com espera exponencial entre elas
+func withRetries(ctx context.Context, name string, retries int, logger *slog.Logger, attempt func() (retry bool, err error)) error {
+	delay := retryBaseDelay
+	for n := 0; ; n++ {
+		retry, err := attempt()
+		if err == nil {
+			return nil
+		}
+		if !retry || n >= retries {
+			return err
+		}
+
+		logger.Debug("Notification delivery failed, retrying",
+			"notifier", name,
+			"attempt", n+1,
+			"delay", delay.String(),
+			"error", err,
+		)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"text/template"
+
+	"gaa/file-organizer/src/config"
+)
+
+// defaultEmailSubject é o assunto dos e-mails sem subject configurado
+const defaultEmailSubject = `[gaa-organizer] {{.Outcome}}: {{.Filename}}`
+
+// defaultEmailBody é o corpo dos e-mails sem body configurado
+const defaultEmailBody = `Outcome:     {{.Outcome}}
+Monitor:     {{.Monitor}}
+{{- if .Rule}}
+Rule:        {{.Rule}}{{end}}
+File:        {{.Source}}
+Size:        {{size .Size}}
+{{- if .Destination}}
+Destination: {{.Destination}}{{end}}
+{{- if .Error}}
+Error:       {{.Error}}{{end}}
+Time:        {{.Time.Format "2006-01-02 15:04:05"}}
+`
+
+// Email envia um e-mail para cada job que passa pelo filtro
+// Write só enfileira; o envio (com novas tentativas) é feito em segundo plano
+type Email struct {
+	*dispatcher
+	config  *config.Email
+	mailer  *mailer
+	subject *template.Template
+	body    *template.Template
+}
+
+// NewEmail cria o notificador de e-mail e inicia o envio em segundo plano
+func NewEmail(cfg *config.Email, mailer *mailer, logger *slog.Logger) (*Email, error) {
+	e := &Email{config: cfg, mailer: mailer}
+
+	var err error
+	if e.subject, err = parseTemplate(cfg.Name, cfg.Subject, defaultEmailSubject); err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	if e.body, err = parseTemplate(cfg.Name, cfg.Body, defaultEmailBody); err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+
+	e.dispatcher = newDispatcher(cfg.Name, &cfg.NotifyFilter, cfg.QueueLen(), e.Send, logger)
+	return e, nil
+}
+
+// Send envia o e-mail agora, com novas tentativas para erros de rede e respostas 4xx
+func (e *Email) Send(ctx context.Context, event Event) error {
+	subject, err := render(e.subject, event)
+	if err != nil {
+		return fmt.Errorf("failed to render subject template: %w", err)
+	}
+	body, err := render(e.body, event)
+	if err != nil {
+		return fmt.Errorf("failed to render body template: %w", err)
+	}
+	return withRetries(ctx, e.config.Name, e.config.RetryCount(), e.logger, func() (bool, error) {
+		return e.mailer.send(ctx, e.config.To, subject, body)
+	})
+}
+
+// parseTemplate compila o template configurado (ou o padrão, se text for vazio)
+func parseTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	return template.New(name).Funcs(config.TemplateFuncs).Parse(text)
+}
+
+// render executa o template com data e retorna o texto gerado
+func render(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"gaa/file-organizer/src/audit"
+	"gaa/file-organizer/src/config"
+)
+
+// Event é o resultado de um job, disponível nos templates das notificações
+// Os campos do registro de auditoria (.Outcome, .Monitor, .Rule, .Source, .Error...) ficam no nível de cima
+type Event struct {
+	audit.Record
+	Filename    string `json:"filename"`              // Nome do arquivo de origem
+	Destination string `json:"destination,omitempty"` // Último destino gravado
+}
+
+// NewEvent cria o evento de um registro de auditoria
+func NewEvent(record audit.Record) Event {
+	event := Event{Record: record, Filename: filepath.Base(record.Source)}
+	if len(record.Destinations) > 0 {
+		event.Destination = record.Destinations[len(record.Destinations)-1]
+	}
+	return event
+}
+
+// SampleEvent cria um evento fictício com o resultado informado (comando notify-test)
+func SampleEvent(monitor, rule, outcome string) Event {
+	record := audit.Record{
+		Time:       time.Now(),
+		Outcome:    outcome,
+		Monitor:    monitor,
+		Rule:       rule,
+		Action:     "move",
+		Source:     "/tmp/gaa-notify-test/relatorio.pdf",
+		Size:       1024,
+		DurationMs: 12,
+	}
+	switch outcome {
+	case audit.OutcomeFailed:
+		record.Error = "failed to move file: permission denied (notify-test)"
+	case audit.OutcomeSuccess, audit.OutcomeOverflow:
+		record.Destinations = []string{"/tmp/gaa-notify-test/organized/relatorio.pdf"}
+	}
+	return NewEvent(record)
+}
+
+// Notifier é um destino de notificações (webhook, e-mail ou resumo diário)
+// Como audit.Sink recebe o registro de cada job e envia em segundo plano
+type Notifier interface {
+	audit.Sink
+	Name() string
+	Send(ctx context.Context, event Event) error // Envio imediato, sem filtro nem fila (comando notify-test)
+}
+
+// New cria os notificadores configurados em settings.notifications
+func New(settings *config.Notifications, logger *slog.Logger) ([]Notifier, error) {
+	var notifiers []Notifier
+	fail := func(err error) ([]Notifier, error) {
+		for _, notifier := range notifiers {
+			notifier.Close()
+		}
+		return nil, err
+	}
+
+	for i := range settings.Webhooks {
+		webhook, err := NewWebhook(&settings.Webhooks[i], logger)
+		if err != nil {
+			return fail(fmt.Errorf("webhook '%s': %w", settings.Webhooks[i].Name, err))
+		}
+		notifiers = append(notifiers, webhook)
+	}
+
+	if len(settings.Emails) == 0 && settings.Digest == nil {
+		return notifiers, nil
+	}
+	mailer, err := newMailer(&settings.SMTP)
+	if err != nil {
+		return fail(fmt.Errorf("smtp: %w", err))
+	}
+
+	for i := range settings.Emails {
+		email, err := NewEmail(&settings.Emails[i], mailer, logger)
+		if err != nil {
+			return fail(fmt.Errorf("email '%s': %w", settings.Emails[i].Name, err))
+		}
+		notifiers = append(notifiers, email)
+	}
+
+	if settings.Digest != nil {
+		digest, err := NewDigest(settings.Digest, mailer, logger)
+		if err != nil {
+			return fail(fmt.Errorf("digest: %w", err))
+		}
+		notifiers = append(notifiers, digest)
+	}
+	return notifiers, nil
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+
+	"gaa/file-organizer/src/config"
+)
+
+// mailer envia e-mails pelo servidor de settings.notifications.smtp
+// Cada envio abre uma conexão nova: as mensagens são poucas e o servidor pode derrubar conexões ociosas
+type mailer struct {
+	config   *config.SMTP
+	password string
+	from     *mail.Address
+}
+
+// newMailer prepara o envio pelo servidor SMTP (lê a senha do password_file, se definido)
+func newMailer(cfg *config.SMTP) (*mailer, error) {
+	password, err := cfg.LoadPassword()
+	if err != nil {
+		return nil, err
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	return &mailer{config: cfg, password: password, from: from}, nil
+}
+
+// send faz uma tentativa de envio e indica se vale tentar de novo em caso de erro
+// Respostas 5xx do servidor (destinatário inexistente, autenticação recusada) são definitivas
+func (m *mailer) send(ctx context.Context, to []string, subject, body string) (retry bool, err error) {
+	message, err := m.message(to, subject, body)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.SMTPTimeout())
+	defer cancel()
+
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return true, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+
+	// O timeout vale para a conversa inteira, não só para a conexão
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := m.deliver(conn, to, message); err != nil {
+		return retryable(err), err
+	}
+	return false, nil
+}
+
+// dial conecta ao servidor, já em TLS no modo "tls"
+func (m *mailer) dial(ctx context.Context) (net.Conn, error) {
+	if m.config.SecurityMode() == config.SMTPSecurityTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: m.config.Host}}
+		return dialer.DialContext(ctx, "tcp", m.config.Address())
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", m.config.Address())
+}
+
+// deliver conduz a conversa SMTP: STARTTLS, autenticação, remetente, destinatários e mensagem
+func (m *mailer) deliver(conn net.Conn, to []string, message []byte) error {
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		return fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if hostname, err := os.Hostname(); err == nil {
+		if err := client.Hello(hostname); err != nil {
+			return fmt.Errorf("SMTP HELO failed: %w", err)
+		}
+	}
+
+	if m.config.SecurityMode() == config.SMTPSecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server does not support STARTTLS (set security: tls or none)")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if m.config.Username != "" {
+		// PlainAuth só envia a senha com TLS ou para localhost (a validação garante um dos dois)
+		auth := smtp.PlainAuth("", m.config.Username, m.password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, address := range to {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid recipient '%s': %w", address, err)
+		}
+		if err := client.Rcpt(parsed.Address); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", parsed.Address, err)
+		}
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := data.Write(message); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+	return client.Quit()
+}
+
+// message monta a mensagem (cabeçalhos e corpo em texto, quoted-printable)
+func (m *mailer) message(to []string, subject, body string) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message id: %w", err)
+	}
+	domain := m.from.Address[strings.LastIndex(m.from.Address, "@")+1:]
+
+	// Quebras de linha no assunto renderizado injetariam cabeçalhos
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("Auto-Submitted: auto-generated\r\n")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// retryable indica se o erro de envio é temporário (rede, timeout ou resposta 4xx do servidor)
+func retryable(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code < 500
+	}
+	return true
+}
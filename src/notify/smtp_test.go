@@ -0,0 +1,270 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"gaa/file-organizer/src/audit"
+	"gaa/file-organizer/src/config"
+)
+
+// smtpMessage é uma mensagem recebida pelo servidor de teste
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer é um servidor SMTP mínimo (sem TLS nem autenticação) que registra as mensagens
+// e responde ao RCPT com rcptReply
+type smtpServer struct {
+	listener  net.Listener
+	rcptReply string
+
+	mu       sync.Mutex
+	conns    int
+	messages []smtpMessage
+}
+
+// newSMTPServer inicia o servidor de teste em uma porta livre de 127.0.0.1
+func newSMTPServer(t *testing.T, rcptReply string) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{listener: listener, rcptReply: rcptReply}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// serve conduz a conversa SMTP de uma conexão
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var message smtpMessage
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line[:min(4, len(line))])
+
+		switch command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			message = smtpMessage{from: smtpArgument(line)}
+			reply("250 OK")
+		case "RCPT":
+			message.to = append(message.to, smtpArgument(line))
+			reply(s.rcptReply)
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			message.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, message)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// smtpArgument extrai o endereço de "MAIL FROM:<...>" ou "RCPT TO:<...>"
+func smtpArgument(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+// Messages retorna as mensagens recebidas até agora
+func (s *smtpServer) Messages() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// Conns retorna quantas conexões o servidor recebeu
+func (s *smtpServer) Conns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+// newTestMailer cria um mailer para o servidor de teste
+func newTestMailer(t *testing.T, s *smtpServer) *mailer {
+	t.Helper()
+	cfg := &config.SMTP{
+		Host:     "127.0.0.1",
+		Port:     s.listener.Addr().(*net.TCPAddr).Port,
+		Security: config.SMTPSecurityNone,
+		From:     "GAA <gaa@example.com>",
+	}
+	m, err := newMailer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMailerSend(t *testing.T) {
+	server := newSMTPServer(t, "250 OK")
+	m := newTestMailer(t, server)
+
+	subject := "Relatório concluído: ação necessária"
+	body := "Olá,\nO arquivo relatório.pdf foi organizado.\n" + strings.Repeat("linha longa ", 20) + "\n"
+	retry, err := m.send(context.Background(), []string{"Equipe <equipe@example.com>", "admin@example.com"}, subject, body)
+	if err != nil {
+		t.Fatalf("send failed (retry=%v): %v", retry, err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+	if got.from != "gaa@example.com" {
+		t.Errorf("MAIL FROM = %q, want gaa@example.com", got.from)
+	}
+	if want := []string{"equipe@example.com", "admin@example.com"}; !slices.Equal(got.to, want) {
+		t.Errorf("RCPT TO = %v, want %v", got.to, want)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	rawSubject := parsed.Header.Get("Subject")
+	if !strings.HasPrefix(rawSubject, "=?utf-8?q?") {
+		t.Errorf("Subject = %q, want Q-encoded", rawSubject)
+	}
+	var decoder mime.WordDecoder
+	if decoded, err := decoder.DecodeHeader(rawSubject); err != nil || decoded != subject {
+		t.Errorf("decoded Subject = %q (%v), want %q", decoded, err, subject)
+	}
+	if encoding := parsed.Header.Get("Content-Transfer-Encoding"); encoding != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q, want quoted-printable", encoding)
+	}
+
+	raw, _ := io.ReadAll(parsed.Body)
+	for line := range strings.SplitSeq(string(raw), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("body line longer than 76 characters: %q", line)
+		}
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatalf("invalid quoted-printable body: %v", err)
+	}
+	// O corpo vai com quebras de linha CRLF, como exige o SMTP
+	if got := strings.ReplaceAll(string(decoded), "\r\n", "\n"); got != body {
+		t.Errorf("decoded body = %q, want %q", got, body)
+	}
+}
+
+func TestMailerSendRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		rcptReply string
+		wantRetry bool
+	}{
+		{"temporary failure", "450 Mailbox busy", true},
+		{"permanent failure", "550 No such user", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPServer(t, tt.rcptReply)
+			m := newTestMailer(t, server)
+
+			retry, err := m.send(context.Background(), []string{"admin@example.com"}, "Teste", "Teste\n")
+			if err == nil {
+				t.Fatal("send succeeded, want error")
+			}
+			if retry != tt.wantRetry {
+				t.Errorf("retry = %v, want %v (error: %v)", retry, tt.wantRetry, err)
+			}
+			if len(server.Messages()) != 0 {
+				t.Error("message delivered despite rejected recipient")
+			}
+		})
+	}
+}
+
+func TestEmailRetries(t *testing.T) {
+	shortRetries(t)
+	tests := []struct {
+		name      string
+		rcptReply string
+		wantConns int
+	}{
+		{"temporary failure is retried", "451 Try again later", 3},
+		{"permanent failure is not retried", "550 No such user", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPServer(t, tt.rcptReply)
+			retries := 2
+			email, err := NewEmail(&config.Email{
+				Name:     "test",
+				To:       []string{"admin@example.com"},
+				Delivery: config.Delivery{Retries: &retries},
+			}, newTestMailer(t, server), testLogger)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { email.Close() })
+
+			event := NewEvent(testRecord("docs", "pdf", audit.OutcomeFailed, "/in/a.pdf"))
+			if err := email.Send(context.Background(), event); err == nil {
+				t.Fatal("Send succeeded, want error")
+			}
+			if conns := server.Conns(); conns != tt.wantConns {
+				t.Errorf("got %d connections, want %d", conns, tt.wantConns)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"text/template"
+
+	"gaa/file-organizer/src/config"
+)
+
+// Webhook envia uma requisição HTTP para cada job que passa pelo filtro
+// Write só enfileira; o envio (com novas tentativas) é feito em segundo plano
+type Webhook struct {
+	*dispatcher
+	config *config.Webhook
+	body   *template.Template // nil = evento em JSON
+	client *http.Client
+}
+
+// NewWebhook cria o webhook e inicia o envio em segundo plano
+func NewWebhook(cfg *config.Webhook, logger *slog.Logger) (*Webhook, error) {
+	w := &Webhook{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.WebhookTimeout()},
+	}
+	if cfg.Body != "" {
+		body, err := template.New(cfg.Name).Funcs(config.TemplateFuncs).Parse(cfg.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %w", err)
+		}
+		w.body = body
+	}
+
+	w.dispatcher = newDispatcher(cfg.Name, &cfg.NotifyFilter, cfg.QueueLen(), w.Send, logger)
+	return w, nil
+}
+
+// Send envia a notificação agora, com novas tentativas para erros de rede, 429 e 5xx
+func (w *Webhook) Send(ctx context.Context, event Event) error {
+	body, err := w.render(event)
+	if err != nil {
+		return err
+	}
+	return withRetries(ctx, w.config.Name, w.config.RetryCount(), w.logger, func() (bool, error) {
+		return w.deliver(ctx, body)
+	})
+}
+
+// render monta o corpo da requisição a partir do template (ou o evento em JSON)
+func (w *Webhook) render(event Event) ([]byte, error) {
+	if w.body == nil {
+		return json.Marshal(event)
+	}
+
+	var buf bytes.Buffer
+	if err := w.body.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render body template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// deliver faz uma tentativa de envio e indica se vale tentar de novo em caso de erro
+func (w *Webhook) deliver(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, w.config.WebhookMethod(), w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gaa-organizer")
+	for name, value := range w.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook rejected: %s", resp.Status)
+	}
+	return false, nil
+}
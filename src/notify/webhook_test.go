@@ -0,0 +1,330 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gaa/file-organizer/src/audit"
+	"gaa/file-organizer/src/config"
+)
+
+// testLogger descarta os logs dos notificadores nos testes
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// webhookRequest é uma requisição recebida pelo servidor de teste
+type webhookRequest struct {
+	method string
+	header http.Header
+	body   string
+}
+
+// webhookServer é um servidor HTTP que registra as requisições e responde com os status de statuses
+// (o último se repete); release, se não for nil, segura cada resposta até ser fechado
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []webhookRequest
+	statuses []int
+	release  chan struct{}
+	received chan struct{} // recebe um sinal a cada requisição
+}
+
+// newWebhookServer inicia o servidor de teste
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusOK}
+	}
+
+	s := &webhookServer{statuses: statuses, received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, webhookRequest{method: r.Method, header: r.Header.Clone(), body: string(body)})
+		status := s.statuses[min(len(s.requests), len(s.statuses))-1]
+		release := s.release
+		s.mu.Unlock()
+		s.received <- struct{}{}
+
+		if release != nil {
+			<-release
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Requests retorna as requisições recebidas até agora
+func (s *webhookServer) Requests() []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// newTestWebhook cria um webhook para o servidor de teste, fechado ao fim do teste
+func newTestWebhook(t *testing.T, cfg config.Webhook) *Webhook {
+	t.Helper()
+	if cfg.Name == "" {
+		cfg.Name = "test"
+	}
+	webhook, err := NewWebhook(&cfg, testLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { webhook.Close() })
+	return webhook
+}
+
+// shortRetries encurta a espera entre tentativas durante o teste
+func shortRetries(t *testing.T) {
+	t.Helper()
+	previous := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = previous })
+}
+
+// testRecord cria um registro de auditoria de um job
+func testRecord(monitor, rule, outcome, source string) audit.Record {
+	return audit.Record{
+		Time:         time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC),
+		Outcome:      outcome,
+		Monitor:      monitor,
+		Rule:         rule,
+		Action:       "move",
+		Source:       source,
+		Destinations: []string{"/srv/docs/" + rule + "/relatorio.pdf"},
+		Size:         2048,
+	}
+}
+
+func TestWebhookBodyTemplateAndHeaders(t *testing.T) {
+	server := newWebhookServer(t)
+	webhook := newTestWebhook(t, config.Webhook{
+		URL:     server.URL,
+		Method:  http.MethodPut,
+		Headers: map[string]string{"Authorization": "Bearer secret", "X-Source": "gaa"},
+		Body:    `{"text": {{json (printf "%s: %s (%s)" .Outcome .Filename (size .Size))}}, "to": {{json .Destination}}}`,
+	})
+
+	event := NewEvent(testRecord("Downloads", "pdfs", audit.OutcomeSuccess, "/home/ana/Downloads/relatorio \"final\".pdf"))
+	if err := webhook.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	request := requests[0]
+	if request.method != http.MethodPut {
+		t.Errorf("method = %s, want PUT", request.method)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":  "application/json",
+		"User-Agent":    "gaa-organizer",
+		"Authorization": "Bearer secret",
+		"X-Source":      "gaa",
+	} {
+		if got := request.header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+
+	var body struct {
+		Text string `json:"text"`
+		To   string `json:"to"`
+	}
+	if err := json.Unmarshal([]byte(request.body), &body); err != nil {
+		t.Fatalf("body is not valid JSON: %v\n%s", err, request.body)
+	}
+	if want := `success: relatorio "final".pdf (2.0KB)`; body.Text != want {
+		t.Errorf("text = %q, want %q", body.Text, want)
+	}
+	if want := "/srv/docs/pdfs/relatorio.pdf"; body.To != want {
+		t.Errorf("to = %q, want %q", body.To, want)
+	}
+}
+
+func TestWebhookDefaultBodyIsEventJSON(t *testing.T) {
+	server := newWebhookServer(t)
+	webhook := newTestWebhook(t, config.Webhook{URL: server.URL})
+
+	record := testRecord("Downloads", "pdfs", audit.OutcomeFailed, "/home/ana/Downloads/a.pdf")
+	record.Error = "permission denied"
+	if err := webhook.Send(context.Background(), NewEvent(record)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if requests[0].method != http.MethodPost {
+		t.Errorf("method = %s, want POST", requests[0].method)
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(requests[0].body), &event); err != nil {
+		t.Fatalf("body is not valid JSON: %v", err)
+	}
+	if event.Filename != "a.pdf" || event.Outcome != audit.OutcomeFailed || event.Error != "permission denied" || event.Monitor != "Downloads" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestWebhookFilter(t *testing.T) {
+	records := []audit.Record{
+		testRecord("Downloads", "pdfs", audit.OutcomeSuccess, "/in/1.pdf"),
+		testRecord("Downloads", "pdfs", audit.OutcomeFailed, "/in/2.pdf"),
+		testRecord("Downloads", "images", audit.OutcomeFailed, "/in/3.png"),
+		testRecord("Scans", "pdfs", audit.OutcomeFailed, "/in/4.pdf"),
+		testRecord("Downloads", "", audit.OutcomeUnmatched, "/in/5.txt"),
+	}
+
+	tests := []struct {
+		name   string
+		filter config.NotifyFilter
+		want   []string
+	}{
+		{"no filter", config.NotifyFilter{}, []string{"1.pdf", "2.pdf", "3.png", "4.pdf", "5.txt"}},
+		{"monitor", config.NotifyFilter{Monitors: []string{"Scans"}}, []string{"4.pdf"}},
+		{"rule", config.NotifyFilter{Rules: []string{"pdfs"}}, []string{"1.pdf", "2.pdf", "4.pdf"}},
+		{"outcome", config.NotifyFilter{Outcomes: []string{audit.OutcomeFailed, audit.OutcomeUnmatched}}, []string{"2.pdf", "3.png", "4.pdf", "5.txt"}},
+		{"combined", config.NotifyFilter{Monitors: []string{"Downloads"}, Rules: []string{"pdfs"}, Outcomes: []string{audit.OutcomeFailed}}, []string{"2.pdf"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhookServer(t)
+			webhook := newTestWebhook(t, config.Webhook{URL: server.URL, Body: `{{.Filename}}`, NotifyFilter: tt.filter})
+
+			for _, record := range records {
+				if err := webhook.Write(record); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			webhook.Close() // Aguarda a fila ser enviada
+
+			var got []string
+			for _, request := range server.Requests() {
+				got = append(got, request.body)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("notified %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	shortRetries(t)
+
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		wantErr  bool
+		wantSent int
+	}{
+		{"success", []int{http.StatusNoContent}, 3, false, 1},
+		{"5xx then success", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, 3, false, 3},
+		{"429 then success", []int{http.StatusTooManyRequests, http.StatusOK}, 3, false, 2},
+		{"5xx until retries run out", []int{http.StatusInternalServerError}, 2, true, 3},
+		{"4xx is not retried", []int{http.StatusBadRequest, http.StatusOK}, 3, true, 1},
+		{"401 is not retried", []int{http.StatusUnauthorized, http.StatusOK}, 3, true, 1},
+		{"no retries", []int{http.StatusServiceUnavailable, http.StatusOK}, 0, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhookServer(t, tt.statuses...)
+			retries := tt.retries
+			webhook := newTestWebhook(t, config.Webhook{URL: server.URL, Delivery: config.Delivery{Retries: &retries}})
+
+			err := webhook.Send(context.Background(), NewEvent(testRecord("Downloads", "pdfs", audit.OutcomeSuccess, "/in/a.pdf")))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := len(server.Requests()); got != tt.wantSent {
+				t.Errorf("got %d requests, want %d", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestWebhookNetworkErrorIsRetried(t *testing.T) {
+	shortRetries(t)
+
+	// O servidor derruba a conexão sem responder: erro de rede, não de HTTP
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	retries := 2
+	webhook := newTestWebhook(t, config.Webhook{URL: server.URL, Delivery: config.Delivery{Retries: &retries}})
+	if err := webhook.Send(context.Background(), NewEvent(testRecord("Downloads", "pdfs", audit.OutcomeSuccess, "/in/a.pdf"))); err == nil {
+		t.Fatal("expected an error when the connection is dropped")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != retries+1 {
+		t.Errorf("got %d attempts, want %d", attempts, retries+1)
+	}
+}
+
+func TestWebhookDropsWhenQueueIsFull(t *testing.T) {
+	server := newWebhookServer(t)
+	server.release = make(chan struct{})
+	webhook := newTestWebhook(t, config.Webhook{URL: server.URL, Body: `{{.Filename}}`, Delivery: config.Delivery{QueueSize: 1}})
+
+	// O primeiro job fica preso no envio; o segundo ocupa a fila; os seguintes são descartados
+	webhook.Write(testRecord("Downloads", "pdfs", audit.OutcomeSuccess, "/in/1.pdf"))
+	select {
+	case <-server.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first notification was not sent")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, name := range []string{"2.pdf", "3.pdf", "4.pdf"} {
+			webhook.Write(testRecord("Downloads", "pdfs", audit.OutcomeSuccess, "/in/"+name))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked with a full queue")
+	}
+
+	close(server.release)
+	webhook.Close()
+
+	var got []string
+	for _, request := range server.Requests() {
+		got = append(got, request.body)
+	}
+	if want := []string{"1.pdf", "2.pdf"}; !slices.Equal(got, want) {
+		t.Errorf("notified %v, want %v", got, want)
+	}
+}
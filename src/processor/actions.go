@@ -0,0 +1,447 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"gaa/file-organizer/src/config"
+)
+
+// Ações suportadas por uma regra
+const (
+	ActionMove        = "move"          // Move para o destino (cópia nos destinos extras)
+	ActionCopy        = "copy"          // Copia para cada destino, mantendo o original
+	ActionHardlink    = "hardlink"      // Cria um hardlink em cada destino (mesmo volume)
+	ActionSymlink     = "symlink"       // Cria em cada destino um symlink apontando para o original
+	ActionMoveAndLink = "move_and_link" // Move e deixa na origem um symlink para o novo local
+	ActionDelete      = "delete"        // Remove o arquivo
+)
+
+// Result descreve o resultado da execução da ação de uma regra
+type Result struct {
+	Action       string
+	Destinations []string // Caminhos finais criados em cada destino
+	Skipped      bool     // Nada foi feito (arquivo sumiu ou conflict_strategy skip)
+	Overflow     bool     // Destino cheio: o arquivo foi enviado para o overflow_destination
+	Size         int64    // Tamanho do arquivo de origem
+	SHA256       string   // Hash do conteúdo, quando calculado pela ação (dedupe, pipeline checksum)
+}
+
+// Execute executa a ação da regra para o arquivo, em todos os destinos da regra
+// Regras com pipeline executam as etapas do pipeline no lugar da ação
+// O cancelamento de ctx (shutdown) interrompe a ação entre uma etapa e outra; o erro retornado
+// contém ctx.Err() e o arquivo fica na origem para ser processado de novo
+func Execute(ctx context.Context, sourcePath, monitor string, rule *config.Rule, logger *slog.Logger) (*Result, error) {
+	action := rule.ActionName()
+	result := &Result{Action: action}
+
+	// Verificar se arquivo fonte ainda existe
+	sourceInfo, err := os.Lstat(sourcePath)
+	if os.IsNotExist(err) {
+		logger.Warn("Source file no longer exists, skipping", "file", sourcePath)
+		result.Skipped = true
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to stat source file: %w", err)
+	}
+	if !sourceInfo.Mode().IsRegular() {
+		logger.Debug("Source is not a regular file (e.g. a link left by move_and_link), skipping", "path", sourcePath)
+		result.Skipped = true
+		return result, nil
+	}
+
+	result.Size = sourceInfo.Size()
+
+	if len(rule.Pipeline) > 0 {
+		pipelineResult, err := RunPipeline(ctx, sourcePath, monitor, rule, logger)
+		pipelineResult.Size = result.Size
+		return pipelineResult, err
+	}
+
+	// Nome no destino (template rename da regra), o mesmo em todos os destinos
+	destName, err := DestinationName(sourcePath, monitor, rule)
+	if err != nil {
+		return result, err
+	}
+
+	destinations := rule.AllDestinations()
+
+	// Cotas e espaço livre verificados em todos os destinos antes de gravar em qualquer um
+	err = preflight(sourcePath, sourceInfo.Size(), destinations, action, rule, logger)
+
+	// Hash do conteúdo para consultar o índice de deduplicação dos destinos
+	hash := ""
+	if err == nil && rule.Dedupe != "" {
+		if hash, _, err = HashFile(sourcePath); err != nil {
+			err = fmt.Errorf("failed to hash file for dedupe: %w", err)
+		}
+		result.SHA256 = hash
+	}
+
+	if err == nil {
+		err = runAction(ctx, sourcePath, destName, hash, destinations, rule, result, logger)
+	}
+	if err != nil {
+		return result, handleCapacityError(ctx, sourcePath, destName, rule, result, err, logger)
+	}
+
+	result.Skipped = action != ActionDelete && len(result.Destinations) == 0
+	return result, nil
+}
+
+// handleCapacityError trata falhas por falta de espaço ou cota excedida: o arquivo vai para o
+// overflow_destination da regra, se configurado, ou permanece na origem
+func handleCapacityError(ctx context.Context, sourcePath, destName string, rule *config.Rule, result *Result, err error, logger *slog.Logger) error {
+	if !errors.Is(err, ErrQuotaExceeded) && !errors.Is(err, ErrInsufficientSpace) {
+		return err
+	}
+
+	// Links não ocupam espaço no destino de overflow: apenas move e copy usam o overflow
+	action := rule.ActionName()
+	if rule.OverflowDestination == "" || (action != ActionMove && action != ActionMoveAndLink && action != ActionCopy) {
+		logger.Warn("Destination is full, file left in place", "file", sourcePath, "reason", err.Error())
+		return err
+	}
+	if _, statErr := os.Lstat(sourcePath); statErr != nil {
+		return err
+	}
+
+	destPath, overflowErr := sendToOverflow(ctx, sourcePath, destName, rule, err, logger)
+	if overflowErr != nil {
+		return overflowErr
+	}
+	result.Overflow = true
+	result.Destinations = append(result.Destinations, destPath)
+	return nil
+}
+
+// runAction executa a ação da regra nos destinos, registrando os caminhos criados em result
+// hash é o SHA-256 do arquivo quando a regra usa deduplicação ("" caso contrário)
+// Entre um destino e outro, o cancelamento de ctx interrompe a ação
+func runAction(ctx context.Context, sourcePath, destName, hash string, destinations []string, rule *config.Rule, result *Result, logger *slog.Logger) error {
+	action := result.Action
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("action not started: %w", err)
+	}
+
+	switch action {
+	case ActionMove, ActionMoveAndLink:
+		// Destinos extras recebem cópias; o último recebe o arquivo original
+		for _, destDir := range destinations[:len(destinations)-1] {
+			if err := result.add(copyDeduped(ctx, sourcePath, destDir, destName, hash, rule, logger)); err != nil {
+				return err
+			}
+		}
+		destPath, err := moveDeduped(ctx, sourcePath, destinations[len(destinations)-1], destName, hash, rule, logger)
+		if err := result.add(destPath, err); err != nil {
+			return err
+		}
+
+		// Deixar um symlink no lugar do original
+		if action == ActionMoveAndLink && destPath != "" {
+			if err := os.Symlink(destPath, sourcePath); err != nil {
+				return fmt.Errorf("file moved to %s but failed to create link at source: %w", destPath, err)
+			}
+			logger.Info("Link created at source", "file", filepath.Base(sourcePath), "target", destPath)
+		}
+
+	case ActionCopy:
+		for _, destDir := range destinations {
+			if err := result.add(copyDeduped(ctx, sourcePath, destDir, destName, hash, rule, logger)); err != nil {
+				return err
+			}
+		}
+
+	case ActionHardlink, ActionSymlink:
+		for _, destDir := range destinations {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("action interrupted: %w", err)
+			}
+			if err := result.add(LinkFile(sourcePath, destDir, destName, rule, action == ActionSymlink, logger)); err != nil {
+				return err
+			}
+		}
+
+	case ActionDelete:
+		if err := os.Remove(sourcePath); err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+		logger.Info("File deleted", "file", filepath.Base(sourcePath))
+
+	default:
+		return fmt.Errorf("unknown action: %s", action)
+	}
+
+	return nil
+}
+
+// copyDeduped copia o arquivo para destDir, aplicando a deduplicação da regra se hash for informado
+func copyDeduped(ctx context.Context, sourcePath, destDir, destName, hash string, rule *config.Rule, logger *slog.Logger) (string, error) {
+	if hash == "" {
+		return CopyFile(ctx, sourcePath, destDir, destName, rule, logger)
+	}
+	if existing, err := identicalCopy(sourcePath, destinationPath(sourcePath, destDir, destName, rule, logger)); err != nil || existing != "" {
+		logExistingCopy(sourcePath, existing, logger)
+		return "", err
+	}
+	if destPath, handled, err := placeDuplicate(sourcePath, destDir, destName, hash, false, rule, logger); handled {
+		return destPath, err
+	}
+
+	destPath, err := CopyFile(ctx, sourcePath, destDir, destName, rule, logger)
+	if err == nil && destPath != "" {
+		indexFile(destDir, destPath, hash, logger)
+	}
+	return destPath, err
+}
+
+// moveDeduped move o arquivo para destDir, aplicando a deduplicação da regra se hash for informado
+func moveDeduped(ctx context.Context, sourcePath, destDir, destName, hash string, rule *config.Rule, logger *slog.Logger) (string, error) {
+	if hash == "" {
+		return MoveFile(ctx, sourcePath, destDir, destName, rule, logger)
+	}
+	if destPath, handled, err := placeDuplicate(sourcePath, destDir, destName, hash, true, rule, logger); handled {
+		return destPath, err
+	}
+
+	destPath, err := MoveFile(ctx, sourcePath, destDir, destName, rule, logger)
+	if err == nil && destPath != "" {
+		indexFile(destDir, destPath, hash, logger)
+	}
+	return destPath, err
+}
+
+// add registra o caminho criado em um destino (ignorando destinos pulados)
+func (r *Result) add(destPath string, err error) error {
+	if err != nil {
+		return err
+	}
+	if destPath != "" {
+		r.Destinations = append(r.Destinations, destPath)
+	}
+	return nil
+}
+
+// CopyFile copia um arquivo para o destination directory mantendo o original
+// destName é o nome no destino (nome original se vazio)
+// A cópia é verificada e renomeada atomicamente para o nome final
+// Retorna o caminho final da cópia, ou "" se foi pulada
+// O cancelamento de ctx descarta a cópia antes de ela ocupar o nome final
+func CopyFile(ctx context.Context, sourcePath, destDir, destName string, rule *config.Rule, logger *slog.Logger) (string, error) {
+	// O original continua na origem e é visto de novo (eventos Write, rescan, ctl reprocess, jobs
+	// pendentes restaurados): não copiar outra vez se o destino já tem o mesmo conteúdo
+	if existing, err := identicalCopy(sourcePath, destinationPath(sourcePath, destDir, destName, rule, logger)); err != nil || existing != "" {
+		logExistingCopy(sourcePath, existing, logger)
+		return "", err
+	}
+
+	destPath, err := resolveDestination(sourcePath, destDir, destName, rule, logger)
+	if err != nil || destPath == "" {
+		return "", err
+	}
+
+	hash, err := copyVerified(ctx, sourcePath, destPath, rule, logger)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	logger.Info("File copied successfully",
+		"file", filepath.Base(sourcePath),
+		"destination", destPath,
+	)
+
+	recordManifest(destDir, destPath, hash, rule, logger)
+	trackPlacement(destDir, destPath)
+
+	return destPath, nil
+}
+
+// LinkFile cria um hardlink (ou symlink) para o arquivo no destination directory
+// destName é o nome no destino (nome original se vazio)
+// O link é criado com um nome temporário e renomeado, substituindo atomicamente um
+// arquivo existente quando a estratégia é overwrite
+// Retorna o caminho final do link, ou "" se foi pulado
+func LinkFile(sourcePath, destDir, destName string, rule *config.Rule, symbolic bool, logger *slog.Logger) (string, error) {
+	// Symlinks apontam para o caminho absoluto do original
+	target, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve source path: %w", err)
+	}
+
+	// O original continua na origem e é visto de novo: não criar outro link se já existe um para ele
+	if existing, err := existingLink(sourcePath, target, destinationPath(sourcePath, destDir, destName, rule, logger), symbolic); err != nil || existing != "" {
+		if existing != "" {
+			logger.Info("Destination already links to the file, skipping", "file", filepath.Base(sourcePath), "destination", existing)
+		}
+		return "", err
+	}
+
+	destPath, err := resolveDestination(sourcePath, destDir, destName, rule, logger)
+	if err != nil || destPath == "" {
+		return "", err
+	}
+
+	tmpPath := filepath.Join(destDir, fmt.Sprintf("%s%d-%s", TempFilePrefix, os.Getpid(), filepath.Base(destPath)))
+	if symbolic {
+		err = os.Symlink(target, tmpPath)
+	} else {
+		err = os.Link(sourcePath, tmpPath)
+	}
+	if err != nil {
+		if !symbolic && isCrossDevice(err) {
+			return "", fmt.Errorf("cannot hardlink across volumes (%s -> %s): %w", sourcePath, destDir, err)
+		}
+		return "", fmt.Errorf("failed to create link: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to rename link: %w", err)
+	}
+
+	kind := "hardlink"
+	if symbolic {
+		kind = "symlink"
+	}
+	logger.Info("File linked successfully",
+		"file", filepath.Base(sourcePath),
+		"destination", destPath,
+		"link", kind,
+	)
+
+	// Hardlinks compartilham o conteúdo verificado pelo manifesto; symlinks não
+	if !symbolic {
+		recordManifest(destDir, destPath, "", rule, logger)
+		trackPlacement(destDir, destPath)
+	}
+
+	return destPath, nil
+}
+
+// CompleteMove conclui o move de um job salvo no shutdown que já tinha colocado o arquivo no destino
+// (processo encerrado entre a cópia para outro volume e a remoção da origem): se o último destino
+// da regra tem uma cópia idêntica, remove a origem (deixando o link de move_and_link) em vez de
+// mover de novo, o que criaria uma segunda cópia com outro nome
+// Retorna o caminho no destino, ou "" se o arquivo ainda precisa ser processado
+func CompleteMove(sourcePath, monitor string, rule *config.Rule, logger *slog.Logger) (string, error) {
+	// Cópias e links já não são repetidos (ver CopyFile e LinkFile); pipelines não são retomados
+	action := rule.ActionName()
+	if len(rule.Pipeline) > 0 || (action != ActionMove && action != ActionMoveAndLink) {
+		return "", nil
+	}
+	if info, err := os.Lstat(sourcePath); err != nil || !info.Mode().IsRegular() {
+		return "", nil
+	}
+
+	destName, err := DestinationName(sourcePath, monitor, rule)
+	if err != nil {
+		return "", nil // O reprocessamento reporta o erro
+	}
+	destinations := rule.AllDestinations()
+	destDir := destinations[len(destinations)-1]
+	existing, err := identicalCopy(sourcePath, destinationPath(sourcePath, destDir, destName, rule, logger))
+	if err != nil || existing == "" {
+		return "", err
+	}
+
+	if err := os.Remove(sourcePath); err != nil {
+		return "", fmt.Errorf("destination already holds %s but failed to remove source: %w", existing, err)
+	}
+	if action == ActionMoveAndLink {
+		if err := os.Symlink(existing, sourcePath); err != nil {
+			return "", fmt.Errorf("file moved to %s but failed to create link at source: %w", existing, err)
+		}
+	}
+	logger.Info("Interrupted move completed, destination already holds the file", "file", sourcePath, "destination", existing)
+	return existing, nil
+}
+
+// findExisting procura, em destPath e nos nomes criados pela estratégia rename (file_1, file_2...),
+// um arquivo aceito por match; a busca termina no primeiro nome livre
+// Retorna o caminho encontrado, ou "" se não houver
+func findExisting(destPath string, match func(path string, info os.FileInfo) (bool, error)) (string, error) {
+	for counter := 0; counter <= maxUniqueNames; counter++ {
+		candidate := destPath
+		if counter > 0 {
+			candidate = uniqueName(destPath, counter)
+		}
+
+		info, err := os.Lstat(candidate)
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to stat destination file: %w", err)
+		}
+
+		ok, err := match(candidate, info)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// identicalCopy retorna uma cópia de sourcePath já existente no destino (mesmo tamanho e SHA-256),
+// ou "" se não houver; o hash da origem só é calculado se algum arquivo tiver o mesmo tamanho
+func identicalCopy(sourcePath, destPath string) (string, error) {
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source file: %w", err)
+	}
+
+	sourceHash := ""
+	return findExisting(destPath, func(path string, info os.FileInfo) (bool, error) {
+		if !info.Mode().IsRegular() || info.Size() != sourceInfo.Size() {
+			return false, nil
+		}
+		if os.SameFile(sourceInfo, info) {
+			return true, nil
+		}
+
+		if sourceHash == "" {
+			hash, _, err := HashFile(sourcePath)
+			if err != nil {
+				return false, fmt.Errorf("failed to hash source file: %w", err)
+			}
+			sourceHash = hash
+		}
+		hash, _, err := HashFile(path)
+		return err == nil && hash == sourceHash, nil
+	})
+}
+
+// existingLink retorna um link para o arquivo já existente no destino, ou "" se não houver
+// Symlinks devem apontar para target; hardlinks devem ser o mesmo arquivo que sourcePath
+func existingLink(sourcePath, target, destPath string, symbolic bool) (string, error) {
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source file: %w", err)
+	}
+
+	return findExisting(destPath, func(path string, info os.FileInfo) (bool, error) {
+		if symbolic {
+			if info.Mode()&os.ModeSymlink == 0 {
+				return false, nil
+			}
+			linkTarget, err := os.Readlink(path)
+			return err == nil && linkTarget == target, nil
+		}
+		return info.Mode().IsRegular() && os.SameFile(sourceInfo, info), nil
+	})
+}
+
+// logExistingCopy registra que a cópia foi pulada porque o destino já tem o mesmo conteúdo
+func logExistingCopy(sourcePath, existing string, logger *slog.Logger) {
+	if existing != "" {
+		logger.Info("Destination already has an identical copy, skipping", "file", filepath.Base(sourcePath), "destination", existing)
+	}
+}
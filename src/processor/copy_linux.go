@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// errShortCopy indica que a chamada de sistema parou de copiar antes do tamanho do arquivo
+// (arquivo encolheu ou sistema de arquivos sem suporte); copyContents passa para o próximo método
+var errShortCopy = errors.New("copy stopped before the end of the file")
+
+// copyContents copia o conteúdo de src para dst tentando, em ordem:
+// reflink (FICLONE), copy_file_range, sendfile e cópia com buffer
+// Cada falha volta os arquivos ao início antes do próximo método
+func copyContents(src, dst *os.File, size int64) (string, error) {
+	// Reflink: instantâneo e sem ocupar espaço extra em FS copy-on-write (btrfs, XFS)
+	if err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())); err == nil {
+		return CopyMethodReflink, nil
+	}
+
+	if err := copyFileRange(src, dst, size); err == nil {
+		return CopyMethodCopyFileRange, nil
+	}
+	if err := resetCopy(src, dst); err != nil {
+		return "", err
+	}
+
+	if err := sendfile(src, dst, size); err == nil {
+		return CopyMethodSendfile, nil
+	}
+	if err := resetCopy(src, dst); err != nil {
+		return "", err
+	}
+
+	return CopyMethodBuffered, copyBuffered(src, dst)
+}
+
+// copyFileRange copia usando copy_file_range(2) até atingir o tamanho do arquivo
+func copyFileRange(src, dst *os.File, size int64) error {
+	var copied int64
+	for copied < size {
+		n, err := unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, int(min(size-copied, 1<<30)), 0)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: copy_file_range copied %d of %d bytes", errShortCopy, copied, size)
+		}
+		copied += int64(n)
+	}
+	return nil
+}
+
+// sendfile copia usando sendfile(2) até atingir o tamanho do arquivo
+func sendfile(src, dst *os.File, size int64) error {
+	var copied int64
+	for copied < size {
+		n, err := unix.Sendfile(int(dst.Fd()), int(src.Fd()), nil, int(min(size-copied, 1<<30)))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: sendfile copied %d of %d bytes", errShortCopy, copied, size)
+		}
+		copied += int64(n)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchmarkFileSize é o tamanho do arquivo copiado nos benchmarks
+const benchmarkFileSize = 64 << 20
+
+// copyMethods são os métodos de cópia comparados, com a mesma assinatura
+var copyMethods = []struct {
+	name string
+	copy func(src, dst *os.File, size int64) error
+}{
+	{CopyMethodCopyFileRange, copyFileRange},
+	{CopyMethodSendfile, sendfile},
+	{CopyMethodBuffered, func(src, dst *os.File, size int64) error { return copyBuffered(src, dst) }},
+}
+
+// openCopyFiles cria um arquivo de origem com size bytes aleatórios e um destino vazio
+// O destino fica em GAA_BENCH_DEST, se definido (ex: outro volume, como nas cópias entre volumes reais)
+func openCopyFiles(tb testing.TB, size int) (src, dst *os.File, data []byte) {
+	tb.Helper()
+
+	data = make([]byte, size)
+	rand.Read(data)
+	srcPath := filepath.Join(tb.TempDir(), "source.bin")
+	if err := os.WriteFile(srcPath, data, 0644); err != nil {
+		tb.Fatal(err)
+	}
+
+	destDir := tb.TempDir()
+	if dir := os.Getenv("GAA_BENCH_DEST"); dir != "" {
+		var err error
+		if destDir, err = os.MkdirTemp(dir, "gaa-bench-*"); err != nil {
+			tb.Fatal(err)
+		}
+		tb.Cleanup(func() { os.RemoveAll(destDir) })
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { src.Close() })
+	dst, err = os.Create(filepath.Join(destDir, "copy.bin"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { dst.Close() })
+	return src, dst, data
+}
+
+// TestCopyMethods verifica que todos os métodos produzem uma cópia idêntica
+func TestCopyMethods(t *testing.T) {
+	for _, method := range copyMethods {
+		t.Run(method.name, func(t *testing.T) {
+			src, dst, data := openCopyFiles(t, 3<<20+123)
+			if err := method.copy(src, dst, int64(len(data))); err != nil {
+				t.Skipf("%s not supported here: %v", method.name, err)
+			}
+
+			copied, err := os.ReadFile(dst.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(copied, data) {
+				t.Fatalf("copy differs from source (%d of %d bytes)", len(copied), len(data))
+			}
+		})
+	}
+}
+
+// TestCopyShortResult verifica que um método que para antes do tamanho esperado (a chamada de sistema
+// retorna 0) falha, e que copyContents passa para o próximo método em vez de aceitar a cópia incompleta
+func TestCopyShortResult(t *testing.T) {
+	for _, method := range copyMethods[:2] {
+		t.Run(method.name, func(t *testing.T) {
+			src, dst, data := openCopyFiles(t, 64<<10)
+			// Tamanho maior que o arquivo: a chamada chega ao fim da origem e retorna 0
+			err := method.copy(src, dst, int64(len(data))+4096)
+			if err == nil {
+				t.Fatal("short copy succeeded, want error")
+			}
+			if !errors.Is(err, errShortCopy) {
+				t.Skipf("%s not supported here: %v", method.name, err)
+			}
+		})
+	}
+
+	src, dst, data := openCopyFiles(t, 64<<10)
+	method, err := copyContents(src, dst, int64(len(data))+4096)
+	if err != nil {
+		t.Fatalf("copyContents failed: %v", err)
+	}
+	if method == CopyMethodCopyFileRange || method == CopyMethodSendfile {
+		t.Errorf("copyContents used %s despite the short copy", method)
+	}
+	copied, err := os.ReadFile(dst.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("copy differs from source (%d of %d bytes, method %s)", len(copied), len(data), method)
+	}
+}
+
+// benchmarkCopy mede um método de cópia de um arquivo de benchmarkFileSize bytes
+func benchmarkCopy(b *testing.B, copy func(src, dst *os.File, size int64) error) {
+	src, dst, _ := openCopyFiles(b, benchmarkFileSize)
+	b.SetBytes(benchmarkFileSize)
+	b.ResetTimer()
+
+	for range b.N {
+		b.StopTimer()
+		if err := resetCopy(src, dst); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := copy(src, dst, benchmarkFileSize); err != nil {
+			b.Skipf("not supported here: %v", err)
+		}
+	}
+}
+
+// BenchmarkCopyFileRange mede a cópia com copy_file_range(2)
+func BenchmarkCopyFileRange(b *testing.B) {
+	benchmarkCopy(b, copyFileRange)
+}
+
+// BenchmarkCopySendfile mede a cópia com sendfile(2)
+func BenchmarkCopySendfile(b *testing.B) {
+	benchmarkCopy(b, sendfile)
+}
+
+// BenchmarkCopyBuffered mede a cópia com io.Copy em espaço de usuário (fallback)
+func BenchmarkCopyBuffered(b *testing.B) {
+	benchmarkCopy(b, func(src, dst *os.File, size int64) error { return copyBuffered(src, dst) })
+}
+
+// BenchmarkCopyContents mede a escolha automática (reflink, copy_file_range, sendfile, buffered)
+func BenchmarkCopyContents(b *testing.B) {
+	benchmarkCopy(b, func(src, dst *os.File, size int64) error {
+		_, err := copyContents(src, dst, size)
+		return err
+	})
+}
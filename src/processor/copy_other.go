@@ -0,0 +1,11 @@
+//go:build !linux
+
+package processor
+
+import "os"
+
+// copyContents copia o conteúdo de src para dst
+// Nesta plataforma apenas a cópia com buffer está disponível
+func copyContents(src, dst *os.File, size int64) (string, error) {
+	return CopyMethodBuffered, copyBuffered(src, dst)
+}
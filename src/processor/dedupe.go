@@ -0,0 +1,488 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gaa/file-organizer/src/config"
+)
+
+// Políticas de deduplicação de uma regra
+const (
+	DedupeDrop     = "drop"     // Descarta o arquivo duplicado
+	DedupeHardlink = "hardlink" // Cria um hardlink para o arquivo já existente no destino
+	DedupeKeep     = "keep"     // Mantém a cópia duplicada e apenas registra no log
+)
+
+// IndexFileName é o arquivo do índice de hashes mantido na raiz de cada destino
+const IndexFileName = ".gaa-index.json"
+
+// IndexEntry descreve um arquivo indexado (caminho relativo à raiz do destino)
+type IndexEntry struct {
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// Index é o índice persistente de hashes dos arquivos de um destino
+type Index struct {
+	mu     sync.Mutex
+	root   string
+	Files  map[string]IndexEntry `json:"files"`
+	byHash map[string][]string
+}
+
+// indexLoad é o carregamento do índice de um destino, feito uma única vez
+type indexLoad struct {
+	done  chan struct{} // fechado quando o carregamento termina
+	index *Index
+	err   error
+}
+
+// indexes mantém os índices carregados (ou em carregamento), um por destino
+// indexesMu protege apenas o mapa: o carregamento, que pode calcular o hash de todo o destino,
+// é feito fora do lock para não bloquear os outros destinos
+var (
+	indexesMu sync.Mutex
+	indexes   = make(map[string]*indexLoad)
+)
+
+// LoadIndex retorna o índice do destino, carregando-o do disco ou construindo-o
+// (hash de todos os arquivos) se ainda não existir
+// Chamadas simultâneas para o mesmo destino esperam o mesmo carregamento
+func LoadIndex(root string, logger *slog.Logger) (*Index, error) {
+	root = filepath.Clean(root)
+
+	indexesMu.Lock()
+	load, loading := indexes[root]
+	if !loading {
+		load = &indexLoad{done: make(chan struct{})}
+		indexes[root] = load
+	}
+	indexesMu.Unlock()
+
+	if loading {
+		<-load.done
+		return load.index, load.err
+	}
+
+	load.index, load.err = readIndex(root, logger)
+	if load.err != nil {
+		// Falhas não ficam em cache: a próxima chamada tenta de novo
+		indexesMu.Lock()
+		if indexes[root] == load {
+			delete(indexes, root)
+		}
+		indexesMu.Unlock()
+	}
+	close(load.done)
+	return load.index, load.err
+}
+
+// readIndex lê o índice do destino do disco, reconstruindo-o se estiver ausente ou corrompido
+func readIndex(root string, logger *slog.Logger) (*Index, error) {
+	index := &Index{root: root, Files: make(map[string]IndexEntry)}
+	data, err := os.ReadFile(filepath.Join(root, IndexFileName))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, index); err != nil {
+			logger.Warn("Dedupe index is corrupt, rebuilding", "path", root, "error", err)
+			index.Files = make(map[string]IndexEntry)
+			if err := index.rebuild(logger); err != nil {
+				return nil, err
+			}
+		}
+	case os.IsNotExist(err):
+		if err := index.rebuild(logger); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to read dedupe index: %w", err)
+	}
+
+	if index.Files == nil {
+		index.Files = make(map[string]IndexEntry)
+	}
+	index.reindexHashes()
+	return index, nil
+}
+
+// rebuild calcula o hash de todos os arquivos do destino e grava o índice
+func (idx *Index) rebuild(logger *slog.Logger) error {
+	logger.Info("Building dedupe index", "path", idx.root)
+	start := time.Now()
+
+	files := make(map[string]IndexEntry)
+	err := walkIndexable(idx.root, func(path string, info os.FileInfo) {
+		hash, size, err := HashFile(path)
+		if err != nil {
+			logger.Warn("Failed to hash file for dedupe index", "file", path, "error", err)
+			return
+		}
+		rel, _ := filepath.Rel(idx.root, path)
+		files[rel] = IndexEntry{SHA256: hash, Size: size, ModTime: info.ModTime()}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build dedupe index: %w", err)
+	}
+
+	idx.Files = files
+	idx.reindexHashes()
+	logger.Info("Dedupe index built", "path", idx.root, "files", len(files), "duration", time.Since(start).String())
+	return idx.save()
+}
+
+// walkIndexable percorre os arquivos regulares do destino, ignorando temporários e arquivos do organizer
+func walkIndexable(root string, fn func(path string, info os.FileInfo)) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() || isOrganizerFile(entry.Name()) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil // Removido durante a varredura
+		}
+		fn(path, info)
+		return nil
+	})
+}
+
+// isOrganizerFile identifica arquivos internos do organizer (temporários, índices e manifestos)
+func isOrganizerFile(name string) bool {
+	return strings.HasPrefix(name, TempFilePrefix) || name == IndexFileName || isManifestFile(name)
+}
+
+// reindexHashes reconstrói o mapa hash -> caminhos
+func (idx *Index) reindexHashes() {
+	idx.byHash = make(map[string][]string, len(idx.Files))
+	for rel, entry := range idx.Files {
+		idx.byHash[entry.SHA256] = append(idx.byHash[entry.SHA256], rel)
+	}
+}
+
+// Find procura um arquivo com o hash informado, confirmando que ele ainda existe e não mudou
+// Retorna o caminho absoluto do arquivo ou "" se não houver duplicata
+func (idx *Index) Find(hash string, size int64) string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, rel := range idx.byHash[hash] {
+		entry := idx.Files[rel]
+		path := filepath.Join(idx.root, rel)
+
+		info, err := os.Stat(path)
+		if err == nil && info.Size() == size && info.ModTime().Equal(entry.ModTime) {
+			return path
+		}
+
+		// Arquivo removido ou alterado fora do organizer
+		idx.forget(rel)
+		if err == nil && info.Mode().IsRegular() {
+			if current, currentSize, err := HashFile(path); err == nil {
+				idx.put(rel, IndexEntry{SHA256: current, Size: currentSize, ModTime: info.ModTime()})
+				if current == hash {
+					return path
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Add registra um arquivo do destino no índice e persiste o índice
+func (idx *Index) Add(path, hash string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(idx.root, path)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.forget(rel)
+	idx.put(rel, IndexEntry{SHA256: hash, Size: info.Size(), ModTime: info.ModTime()})
+	return idx.save()
+}
+
+// put adiciona uma entrada (com o lock já adquirido)
+func (idx *Index) put(rel string, entry IndexEntry) {
+	idx.Files[rel] = entry
+	idx.byHash[entry.SHA256] = append(idx.byHash[entry.SHA256], rel)
+}
+
+// forget remove uma entrada (com o lock já adquirido)
+func (idx *Index) forget(rel string) {
+	entry, ok := idx.Files[rel]
+	if !ok {
+		return
+	}
+	delete(idx.Files, rel)
+
+	paths := idx.byHash[entry.SHA256]
+	for i, p := range paths {
+		if p == rel {
+			idx.byHash[entry.SHA256] = append(paths[:i:i], paths[i+1:]...)
+			break
+		}
+	}
+	if len(idx.byHash[entry.SHA256]) == 0 {
+		delete(idx.byHash, entry.SHA256)
+	}
+}
+
+// save grava o índice atomicamente (temporário + rename)
+func (idx *Index) save() error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to encode dedupe index: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(idx.root, TempFilePrefix+"index-*")
+	if err != nil {
+		return fmt.Errorf("failed to write dedupe index: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Chmod(0644)
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write dedupe index: %w", err)
+	}
+	tmpFile.Close()
+
+	if err := os.Rename(tmpPath, filepath.Join(idx.root, IndexFileName)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write dedupe index: %w", err)
+	}
+	return nil
+}
+
+// DuplicateGroup é um conjunto de arquivos com o mesmo conteúdo
+// Original é o arquivo mantido; Duplicates são as cópias
+type DuplicateGroup struct {
+	SHA256     string
+	Size       int64
+	Original   string
+	Duplicates []string
+}
+
+// DedupeReport resume a deduplicação de uma árvore
+type DedupeReport struct {
+	Files      int
+	Groups     []DuplicateGroup
+	BytesFreed int64
+	Errors     []error
+}
+
+// DedupeTree procura arquivos duplicados em dir e aplica a política
+// ("" apenas relata, drop remove as cópias, hardlink as substitui por hardlinks do original)
+// O original de cada grupo é o arquivo mais antigo. Com drop/hardlink o índice do diretório é reconstruído ao final
+func DedupeTree(dir, policy string, logger *slog.Logger) (*DedupeReport, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	report := &DedupeReport{}
+
+	type fileInfo struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	byHash := make(map[string][]fileInfo)
+
+	err := walkIndexable(dir, func(path string, info os.FileInfo) {
+		hash, size, err := HashFile(path)
+		if err != nil {
+			report.Errors = append(report.Errors, err)
+			return
+		}
+		report.Files++
+		byHash[hash] = append(byHash[hash], fileInfo{path: path, size: size, modTime: info.ModTime()})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	for hash, files := range byHash {
+		if len(files) < 2 {
+			continue
+		}
+
+		// Mais antigo primeiro; empate decidido pelo caminho mais curto
+		sort.Slice(files, func(i, j int) bool {
+			if !files[i].modTime.Equal(files[j].modTime) {
+				return files[i].modTime.Before(files[j].modTime)
+			}
+			if len(files[i].path) != len(files[j].path) {
+				return len(files[i].path) < len(files[j].path)
+			}
+			return files[i].path < files[j].path
+		})
+
+		group := DuplicateGroup{SHA256: hash, Size: files[0].size, Original: files[0].path}
+		for _, duplicate := range files[1:] {
+			// Hardlinks do original já não ocupam espaço
+			if sameFile(files[0].path, duplicate.path) {
+				continue
+			}
+			group.Duplicates = append(group.Duplicates, duplicate.path)
+
+			if err := applyDedupe(files[0].path, duplicate.path, policy); err != nil {
+				report.Errors = append(report.Errors, err)
+				continue
+			}
+			if policy == DedupeDrop || policy == DedupeHardlink {
+				report.BytesFreed += duplicate.size
+				logger.Info("Duplicate file deduplicated", "file", duplicate.path, "original", files[0].path, "policy", policy)
+			}
+		}
+		if len(group.Duplicates) > 0 {
+			report.Groups = append(report.Groups, group)
+		}
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Original < report.Groups[j].Original })
+
+	if policy == "" {
+		return report, nil
+	}
+
+	// Reconstruir o índice do diretório com o estado final
+	indexesMu.Lock()
+	delete(indexes, filepath.Clean(dir))
+	indexesMu.Unlock()
+	os.Remove(filepath.Join(dir, IndexFileName))
+	if _, err := LoadIndex(dir, logger); err != nil {
+		report.Errors = append(report.Errors, err)
+	}
+
+	return report, nil
+}
+
+// applyDedupe aplica a política a uma cópia duplicada de original
+func applyDedupe(original, duplicate, policy string) error {
+	switch policy {
+	case DedupeDrop:
+		if err := os.Remove(duplicate); err != nil {
+			return fmt.Errorf("failed to remove duplicate %s: %w", duplicate, err)
+		}
+	case DedupeHardlink:
+		return replaceWithHardlink(original, duplicate)
+	}
+	return nil
+}
+
+// replaceWithHardlink substitui atomicamente path por um hardlink para original
+func replaceWithHardlink(original, path string) error {
+	tmpPath := filepath.Join(filepath.Dir(path), fmt.Sprintf("%s%d-%s", TempFilePrefix, os.Getpid(), filepath.Base(path)))
+	if err := os.Link(original, tmpPath); err != nil {
+		return fmt.Errorf("failed to hardlink %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace %s with hardlink: %w", path, err)
+	}
+	return nil
+}
+
+// sameFile verifica se os dois caminhos apontam para o mesmo arquivo (hardlinks)
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// findDuplicate procura no índice de destDir um arquivo com o conteúdo informado
+// Falhas ao carregar o índice desativam a deduplicação para o arquivo (retorna "")
+func findDuplicate(destDir, hash string, size int64, logger *slog.Logger) string {
+	index, err := LoadIndex(destDir, logger)
+	if err != nil {
+		logger.Warn("Failed to load dedupe index", "path", destDir, "error", err)
+		return ""
+	}
+	return index.Find(hash, size)
+}
+
+// indexFile registra no índice de destDir o arquivo gravado pelo organizer
+func indexFile(destDir, destPath, hash string, logger *slog.Logger) {
+	index, err := LoadIndex(destDir, logger)
+	if err == nil {
+		err = index.Add(destPath, hash)
+	}
+	if err != nil {
+		logger.Warn("Failed to update dedupe index", "path", destDir, "file", destPath, "error", err)
+	}
+}
+
+// placeDuplicate aplica a política de deduplicação da regra quando destDir já tem o conteúdo do arquivo
+// Retorna handled = false se o arquivo deve ser gravado normalmente (sem duplicata ou política keep)
+// Com move, o arquivo de origem é removido quando a duplicata é descartada ou vira hardlink
+func placeDuplicate(sourcePath, destDir, destName, hash string, move bool, rule *config.Rule, logger *slog.Logger) (destPath string, handled bool, err error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return "", false, nil // O move/cópia normal reporta o erro
+	}
+
+	duplicate := findDuplicate(destDir, hash, info.Size(), logger)
+	if duplicate == "" {
+		return "", false, nil
+	}
+
+	switch rule.Dedupe {
+	case DedupeKeep:
+		logger.Info("Duplicate file kept", "file", filepath.Base(sourcePath), "duplicate_of", duplicate)
+		return "", false, nil
+
+	case DedupeHardlink:
+		if destName == "" {
+			destName = filepath.Base(sourcePath)
+		}
+		destPath, err = LinkFile(duplicate, destDir, destName, rule, false, logger)
+		if err != nil {
+			return "", true, err
+		}
+		if destPath == "" {
+			// Nada a criar (o nome no destino já é a duplicata, ou conflito skip): o conteúdo continua em duplicate
+			destPath = duplicate
+		} else {
+			indexFile(destDir, destPath, hash, logger)
+		}
+
+	case DedupeDrop:
+		destPath = duplicate
+	}
+
+	if move {
+		if err := os.Remove(sourcePath); err != nil {
+			return destPath, true, fmt.Errorf("failed to remove duplicate source file: %w", err)
+		}
+	}
+
+	logger.Info("Duplicate file deduplicated",
+		"file", filepath.Base(sourcePath),
+		"duplicate_of", duplicate,
+		"policy", rule.Dedupe,
+	)
+	return destPath, true, nil
+}
@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gaa/file-organizer/src/config"
+)
+
+func TestPlaceDuplicateHardlinkExistingName(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sourceDir, destDir := t.TempDir(), t.TempDir()
+
+	// O destino já tem o conteúdo com o mesmo nome do arquivo recebido
+	duplicate := filepath.Join(destDir, "relatorio.pdf")
+	if err := os.WriteFile(duplicate, []byte("conteúdo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, _, err := HashFile(duplicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexFile(destDir, duplicate, hash, logger)
+
+	source := filepath.Join(sourceDir, "relatorio.pdf")
+	if err := os.WriteFile(source, []byte("conteúdo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := &config.Rule{Name: "pdf", Monitor: "docs", Dedupe: DedupeHardlink, ConflictStrategy: "rename"}
+	destPath, handled, err := placeDuplicate(source, destDir, "", hash, true, rule, logger)
+	if err != nil {
+		t.Fatalf("placeDuplicate failed: %v", err)
+	}
+	if !handled || destPath != duplicate {
+		t.Errorf("placeDuplicate = (%q, %v), want (%q, true)", destPath, handled, duplicate)
+	}
+	if _, err := os.Stat(source); !os.IsNotExist(err) {
+		t.Errorf("source file not removed after deduplicated move: %v", err)
+	}
+	if entries, _ := os.ReadDir(destDir); len(entries) != 2 { // duplicata e índice
+		t.Errorf("destination has %d entries, want the duplicate and the index", len(entries))
+	}
+}
@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// Classes de erro de um processamento que falhou (label class de gaa_failures_total)
+const (
+	ErrorClassNotFound     = "not_found"    // Arquivo ou diretório sumiu
+	ErrorClassPermission   = "permission"   // Sem permissão na origem ou no destino
+	ErrorClassNoSpace      = "no_space"     // Volume de destino cheio
+	ErrorClassQuota        = "quota"        // max_size/max_files do destino excedido
+	ErrorClassCrossDevice  = "cross_device" // Hardlink entre volumes diferentes
+	ErrorClassVerification = "verification" // Cópia diferente da origem
+	ErrorClassTimeout      = "timeout"      // Comando ou notificação do pipeline excedeu o timeout
+	ErrorClassOther        = "other"
+)
+
+// ErrVerificationFailed indica que a cópia não confere com a origem (tamanho ou SHA-256)
+var ErrVerificationFailed = errors.New("copy verification failed")
+
+// ErrorClass classifica o erro retornado por Execute
+func ErrorClass(err error) string {
+	var timeout interface{ Timeout() bool }
+
+	switch {
+	case errors.Is(err, ErrQuotaExceeded):
+		return ErrorClassQuota
+	case errors.Is(err, ErrInsufficientSpace), errors.Is(err, syscall.ENOSPC):
+		return ErrorClassNoSpace
+	case errors.Is(err, ErrVerificationFailed):
+		return ErrorClassVerification
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorClassNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorClassPermission
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return ErrorClassTimeout
+	case isCrossDevice(err):
+		return ErrorClassCrossDevice
+	default:
+		return ErrorClassOther
+	}
+}
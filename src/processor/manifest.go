@@ -0,0 +1,285 @@
+package processor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gaa/file-organizer/src/config"
+)
+
+// Formatos de manifesto de um destino
+const (
+	ManifestSHA256SUMS = "sha256sums" // Formato do sha256sum: "<hash>  <caminho>"
+	ManifestJSONL      = "jsonl"      // Um objeto JSON por linha
+)
+
+// Nomes dos arquivos de manifesto na raiz do destino
+const (
+	SHA256SUMSFileName    = "SHA256SUMS"
+	ManifestJSONLFileName = "MANIFEST.jsonl"
+)
+
+// ManifestEntry é uma linha do manifesto JSONL
+type ManifestEntry struct {
+	Path    string    `json:"path"`
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	Rule    string    `json:"rule,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// manifestMu serializa as escritas nos manifestos (vários workers podem gravar no mesmo destino)
+var manifestMu sync.Mutex
+
+// manifestFileName retorna o nome do arquivo de manifesto de um formato
+func manifestFileName(format string) string {
+	if format == ManifestJSONL {
+		return ManifestJSONLFileName
+	}
+	return SHA256SUMSFileName
+}
+
+// recordManifest acrescenta o arquivo gravado em destDir ao manifesto do destino, se a regra usa manifesto
+// hash vazio faz o hash ser calculado a partir do arquivo no destino
+func recordManifest(destDir, destPath, hash string, rule *config.Rule, logger *slog.Logger) {
+	if rule.Manifest == "" {
+		return
+	}
+
+	if err := appendManifest(destDir, destPath, hash, rule); err != nil {
+		logger.Error("Failed to record file in manifest",
+			"file", destPath,
+			"manifest", filepath.Join(destDir, manifestFileName(rule.Manifest)),
+			"error", err,
+		)
+	}
+}
+
+// appendManifest grava a linha do arquivo no manifesto e sincroniza o manifesto no disco
+func appendManifest(destDir, destPath, hash string, rule *config.Rule) error {
+	hash, size, err := hashIfNeeded(destPath, hash)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(destDir, destPath)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+
+	var line []byte
+	if rule.Manifest == ManifestJSONL {
+		line, err = json.Marshal(ManifestEntry{Path: rel, SHA256: hash, Size: size, Rule: rule.Name, AddedAt: time.Now()})
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+	} else {
+		line = []byte(fmt.Sprintf("%s  %s\n", hash, rel))
+	}
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	file, err := os.OpenFile(filepath.Join(destDir, manifestFileName(rule.Manifest)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(line); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// hashIfNeeded calcula o hash do arquivo se ainda não for conhecido
+func hashIfNeeded(path, hash string) (string, int64, error) {
+	if hash != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", 0, err
+		}
+		return hash, info.Size(), nil
+	}
+	return HashFile(path)
+}
+
+// VerifyReport é o resultado da verificação de um destino contra seu manifesto
+type VerifyReport struct {
+	Dir        string
+	Manifest   string
+	Verified   int
+	Mismatched []string // Conteúdo diferente do registrado
+	Missing    []string // No manifesto, mas ausentes no disco
+	Extra      []string // No disco, mas fora do manifesto
+	Errors     []error
+}
+
+// OK indica se o destino não tem divergências
+func (r *VerifyReport) OK() bool {
+	return len(r.Mismatched) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Errors) == 0
+}
+
+// FindManifest retorna o manifesto existente em dir (SHA256SUMS ou MANIFEST.jsonl)
+func FindManifest(dir string) (string, string, error) {
+	for _, format := range []string{ManifestSHA256SUMS, ManifestJSONL} {
+		path := filepath.Join(dir, manifestFileName(format))
+		if _, err := os.Stat(path); err == nil {
+			return path, format, nil
+		}
+	}
+	return "", "", fmt.Errorf("no manifest found in %s (expected %s or %s)", dir, SHA256SUMSFileName, ManifestJSONLFileName)
+}
+
+// VerifyManifest recalcula o hash dos arquivos de dir e compara com o manifesto
+// Quando um caminho aparece mais de uma vez (arquivo sobrescrito), vale a última linha
+func VerifyManifest(dir string) (*VerifyReport, error) {
+	manifestPath, format, err := FindManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := readManifest(manifestPath, format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{Dir: dir, Manifest: manifestPath}
+	seen := make(map[string]bool, len(expected))
+
+	err = walkIndexable(dir, func(path string, info os.FileInfo) {
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+
+		hash, ok := expected[rel]
+		if !ok {
+			// Sidecars da etapa checksum acompanham arquivos do manifesto e não são registrados
+			if !strings.HasSuffix(rel, checksumSuffix) {
+				report.Extra = append(report.Extra, rel)
+			}
+			return
+		}
+		seen[rel] = true
+
+		current, _, err := HashFile(path)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf("%s: %w", rel, err))
+			return
+		}
+		if current != hash {
+			report.Mismatched = append(report.Mismatched, rel)
+			return
+		}
+		report.Verified++
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	for rel := range expected {
+		if !seen[rel] {
+			report.Missing = append(report.Missing, rel)
+		}
+	}
+	sort.Strings(report.Missing)
+
+	return report, nil
+}
+
+// readManifest lê o manifesto e retorna caminho relativo -> hash esperado
+func readManifest(path, format string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	expected := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if format == ManifestJSONL {
+			var entry ManifestEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, fmt.Errorf("invalid manifest line %d: %w", lineNumber, err)
+			}
+			expected[entry.Path] = entry.SHA256
+			continue
+		}
+
+		// "<hash>  <caminho>" (o caminho pode ter "*" de modo binário)
+		hash, rel, ok := strings.Cut(line, " ")
+		if !ok || len(hash) != 64 {
+			return nil, fmt.Errorf("invalid manifest line %d: %q", lineNumber, line)
+		}
+		rel = strings.TrimPrefix(strings.TrimPrefix(rel, " "), "*")
+		expected[rel] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return expected, nil
+}
+
+// isManifestFile identifica os arquivos de manifesto
+func isManifestFile(name string) bool {
+	return name == SHA256SUMSFileName || name == ManifestJSONLFileName
+}
+
+// Scrub verifica todos os destinos com manifesto e registra as divergências no log
+// Retorna quantos destinos têm divergências
+func Scrub(dirs []string, logger *slog.Logger) int {
+	failed := 0
+
+	for _, dir := range dirs {
+		start := time.Now()
+		report, err := VerifyManifest(dir)
+		if err != nil {
+			logger.Warn("Integrity scrub skipped destination", "path", dir, "error", err)
+			continue
+		}
+
+		for _, rel := range report.Mismatched {
+			logger.Error("Integrity check failed: file content changed", "path", dir, "file", rel)
+		}
+		for _, rel := range report.Missing {
+			logger.Error("Integrity check failed: file missing", "path", dir, "file", rel)
+		}
+		for _, rel := range report.Extra {
+			logger.Warn("Integrity check: file not in manifest", "path", dir, "file", rel)
+		}
+		for _, err := range report.Errors {
+			logger.Error("Integrity check failed to read file", "path", dir, "error", err)
+		}
+
+		if !report.OK() {
+			failed++
+		}
+		logger.Info("Integrity scrub completed",
+			"path", dir,
+			"verified", report.Verified,
+			"mismatched", len(report.Mismatched),
+			"missing", len(report.Missing),
+			"extra", len(report.Extra),
+			"duration", time.Since(start).String(),
+		)
+	}
+
+	return failed
+}
@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Metadados que podem ser preservados em cópias (opção preserve da regra)
+// Permissões são sempre preservadas; moves no mesmo volume preservam tudo automaticamente
+const (
+	PreserveMtime  = "mtime"  // Datas de modificação e acesso
+	PreserveOwner  = "owner"  // Usuário e grupo dono do arquivo
+	PreserveXattrs = "xattrs" // Atributos estendidos (exceto ACLs)
+	PreserveACL    = "acl"    // ACLs POSIX (armazenadas como atributos estendidos)
+)
+
+// errMetadataUnsupported indica que o metadado não é suportado nesta plataforma
+var errMetadataUnsupported = errors.New("not supported on this platform")
+
+// preserveMetadata copia os metadados do arquivo de origem para a cópia, exceto as datas (ver preserveTimes)
+// Falhas não interrompem o move, mas são reportadas pelo logger
+func preserveMetadata(sourcePath, destPath string, options []string, logger *slog.Logger) {
+	filename := filepath.Base(sourcePath)
+
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		logger.Warn("Failed to read source metadata", "file", filename, "error", err)
+		return
+	}
+
+	// Permissões do arquivo original
+	if err := os.Chmod(destPath, sourceInfo.Mode().Perm()); err != nil {
+		logger.Warn("Failed to preserve file metadata", "file", filename, "metadata", "mode", "error", err)
+	}
+
+	steps := []struct {
+		option string
+		apply  func() error
+	}{
+		{PreserveXattrs, func() error { return copyXattrs(sourcePath, destPath, false) }},
+		{PreserveACL, func() error { return copyXattrs(sourcePath, destPath, true) }},
+		{PreserveOwner, func() error { return copyOwner(sourcePath, destPath) }},
+	}
+
+	for _, step := range steps {
+		if !slices.Contains(options, step.option) {
+			continue
+		}
+		if err := step.apply(); err != nil {
+			logger.Warn("Failed to preserve file metadata",
+				"file", filename,
+				"metadata", step.option,
+				"error", err,
+			)
+			continue
+		}
+		logger.Debug("File metadata preserved", "file", filename, "metadata", step.option)
+	}
+}
+
+// preserveTimes aplica à cópia as datas de acesso e modificação da origem (opção mtime)
+// Deve ser a última alteração antes do rename: ler a cópia (verificação) ou mudar outros metadados
+// pode alterar as datas
+func preserveTimes(sourcePath, destPath string, atime, mtime time.Time, options []string, logger *slog.Logger) {
+	if !slices.Contains(options, PreserveMtime) {
+		return
+	}
+
+	filename := filepath.Base(sourcePath)
+	if err := os.Chtimes(destPath, atime, mtime); err != nil {
+		logger.Warn("Failed to preserve file metadata", "file", filename, "metadata", PreserveMtime, "error", err)
+		return
+	}
+	logger.Debug("File metadata preserved", "file", filename, "metadata", PreserveMtime)
+}
@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+
+package processor
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime retorna a data de último acesso do arquivo
+// Nesta plataforma a data de modificação é usada no lugar
+func accessTime(path string, info os.FileInfo) time.Time {
+	return info.ModTime()
+}
+
+// copyOwner não é suportado nesta plataforma
+func copyOwner(sourcePath, destPath string) error {
+	return errMetadataUnsupported
+}
+
+// copyXattrs não é suportado nesta plataforma
+func copyXattrs(sourcePath, destPath string, aclOnly bool) error {
+	return errMetadataUnsupported
+}
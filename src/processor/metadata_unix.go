@@ -0,0 +1,93 @@
+//go:build linux || darwin
+
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// aclXattrPrefix identifica os atributos estendidos que armazenam ACLs POSIX no Linux
+const aclXattrPrefix = "system.posix_acl_"
+
+// accessTime retorna a data de último acesso do arquivo
+func accessTime(path string, info os.FileInfo) time.Time {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return info.ModTime()
+	}
+	return time.Unix(stat.Atim.Unix())
+}
+
+// copyOwner copia usuário e grupo do arquivo de origem (normalmente exige root)
+func copyOwner(sourcePath, destPath string) error {
+	var stat unix.Stat_t
+	if err := unix.Stat(sourcePath, &stat); err != nil {
+		return err
+	}
+	return os.Lchown(destPath, int(stat.Uid), int(stat.Gid))
+}
+
+// copyXattrs copia atributos estendidos da origem para o destino
+// Com aclOnly, copia apenas os atributos de ACL; caso contrário, todos exceto ACLs
+func copyXattrs(sourcePath, destPath string, aclOnly bool) error {
+	names, err := listXattrs(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, aclXattrPrefix) != aclOnly {
+			continue
+		}
+
+		size, err := unix.Lgetxattr(sourcePath, name, nil)
+		if err != nil {
+			return fmt.Errorf("failed to read attribute %s: %w", name, err)
+		}
+		value := make([]byte, size)
+		if size > 0 {
+			if size, err = unix.Lgetxattr(sourcePath, name, value); err != nil {
+				return fmt.Errorf("failed to read attribute %s: %w", name, err)
+			}
+		}
+
+		if err := unix.Lsetxattr(destPath, name, value[:size], 0); err != nil {
+			return fmt.Errorf("failed to write attribute %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// listXattrs lista os nomes dos atributos estendidos de um arquivo
+func listXattrs(path string) ([]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil {
+		if err == unix.ENOTSUP {
+			return nil, nil // Sistema de arquivos sem suporte: nada a copiar
+		}
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, size)
+	if size, err = unix.Llistxattr(path, buf); err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+
+	var names []string
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
@@ -8,25 +9,46 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/metrics"
+	"gaa/file-organizer/src/naming"
+)
+
+// Métodos de cópia, do mais rápido para o mais lento
+const (
+	CopyMethodReflink       = "reflink"         // Clone copy-on-write (FICLONE), sem duplicar blocos
+	CopyMethodCopyFileRange = "copy_file_range" // Cópia dentro do kernel
+	CopyMethodSendfile      = "sendfile"        // Cópia dentro do kernel (kernels/FS sem copy_file_range)
+	CopyMethodBuffered      = "buffered"        // Leitura/escrita em espaço de usuário
 )
 
-// MoveFile move um arquivo do source para o destination directory
-// aplica a estratégia de conflito especificada se o arquivo já existir
-func MoveFile(sourcePath, destDir, conflictStrategy string, logger *slog.Logger) error {
+// copyBufferSize é o tamanho do buffer da cópia em espaço de usuário
+const copyBufferSize = 1024 * 1024
+
+// TempFilePrefix é o prefixo dos arquivos temporários criados durante cópias entre volumes
+const TempFilePrefix = ".gaa-tmp-"
+
+// MoveFile move um arquivo do source para o destination directory com o nome destName
+// (nome original se vazio) e aplica a estratégia de conflito da regra se o arquivo já existir
+// Retorna o caminho final do arquivo, ou "" se o move foi pulado
+// O cancelamento de ctx interrompe uma cópia entre volumes antes de ela ocupar o nome final;
+// depois disso o move termina (remoção da origem), para o arquivo nunca ficar nos dois lugares
+func MoveFile(ctx context.Context, sourcePath, destDir, destName string, rule *config.Rule, logger *slog.Logger) (string, error) {
 	filename := filepath.Base(sourcePath)
 
 	// Verificar se arquivo fonte ainda existe
 	sourceInfo, err := os.Stat(sourcePath)
 	if os.IsNotExist(err) {
 		logger.Warn("Source file no longer exists, skipping", "file", sourcePath)
-		return nil
+		return "", nil
 	}
 	if err != nil {
-		return fmt.Errorf("failed to stat source file: %w", err)
+		return "", fmt.Errorf("failed to stat source file: %w", err)
 	}
 	if sourceInfo.IsDir() {
 		logger.Warn("Source is a directory, not a file, skipping", "path", sourcePath)
-		return nil
+		return "", nil
 	}
 
 	logger.Debug("Starting file move",
@@ -34,45 +56,30 @@ func MoveFile(sourcePath, destDir, conflictStrategy string, logger *slog.Logger)
 		"dest_dir", destDir,
 		"file_size", sourceInfo.Size())
 
-	// Construir caminho de destino
-	destPath := filepath.Join(destDir, filename)
-
-	// Criar diretório de destino se não existir (antes de qualquer operação)
-	logger.Debug("Ensuring destination directory exists", "path", destDir)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
-	}
-	logger.Debug("Destination directory ready", "path", destDir)
-
-	// Verificar se arquivo de destino já existe
-	if _, statErr := os.Stat(destPath); statErr == nil {
-		// Arquivo já existe - aplicar estratégia de conflito
-		logger.Debug("Destination file already exists, applying conflict strategy",
-			"file", filename,
-			"strategy", conflictStrategy)
-		destPath, err = handleConflict(destPath, conflictStrategy, logger)
-		if err != nil {
-			return err
-		}
+	destPath, err := resolveDestination(sourcePath, destDir, destName, rule, logger)
+	if err != nil || destPath == "" {
+		return "", err
 	}
 
 	// Tentar mover o arquivo
 	logger.Debug("Attempting to move file", "from", sourcePath, "to", destPath)
+	hash := "" // Conhecido apenas quando a cópia foi verificada
 	err = os.Rename(sourcePath, destPath)
 	if err != nil {
 		// Se falhar (provavelmente volumes diferentes), fazer copy + delete
-		if strings.Contains(err.Error(), "cross-device") || strings.Contains(err.Error(), "invalid cross-device link") {
-			logger.Debug("Cross-device move detected, using copy+delete", "file", filename)
-			if err := copyFile(sourcePath, destPath); err != nil {
-				return fmt.Errorf("failed to copy file: %w", err)
+		if isCrossDevice(err) {
+			logger.Debug("Cross-device move detected, using verified copy+delete", "file", filename)
+			if hash, err = copyVerified(ctx, sourcePath, destPath, rule, logger); err != nil {
+				return "", fmt.Errorf("failed to copy file: %w", err)
 			}
 
-			// Remover arquivo original apenas após cópia bem-sucedida
+			// Remover arquivo original apenas após cópia verificada
+			// Se falhar, o arquivo fica duplicado (origem e destino): reportar como erro
 			if err := os.Remove(sourcePath); err != nil {
-				logger.Warn("Failed to remove source file after copy", "file", sourcePath, "error", err)
+				return "", fmt.Errorf("file copied to %s but failed to remove source: %w", destPath, err)
 			}
 		} else {
-			return fmt.Errorf("failed to move file: %w", err)
+			return "", fmt.Errorf("failed to move file: %w", err)
 		}
 	}
 
@@ -81,12 +88,68 @@ func MoveFile(sourcePath, destDir, conflictStrategy string, logger *slog.Logger)
 		"destination", filepath.Base(destPath),
 	)
 
-	return nil
+	recordManifest(destDir, destPath, hash, rule, logger)
+	trackPlacement(destDir, destPath)
+
+	return destPath, nil
+}
+
+// isCrossDevice verifica se o erro de rename indica volumes diferentes
+func isCrossDevice(err error) bool {
+	return strings.Contains(err.Error(), "cross-device") || strings.Contains(err.Error(), "invalid cross-device link")
+}
+
+// resolveDestination garante que o diretório de destino existe e calcula o caminho final,
+// aplicando a sanitização e a estratégia de conflito da regra
+// destName vazio mantém o nome do arquivo de origem
+// Retorna "" se a estratégia for skip e o destino já existir
+func resolveDestination(sourcePath, destDir, destName string, rule *config.Rule, logger *slog.Logger) (string, error) {
+	destPath := destinationPath(sourcePath, destDir, destName, rule, logger)
+	filename := filepath.Base(destPath)
+
+	// Criar diretório de destino se não existir (antes de qualquer operação)
+	logger.Debug("Ensuring destination directory exists", "path", destDir)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	logger.Debug("Destination directory ready", "path", destDir)
+
+	// Verificar se arquivo de destino já existe
+	if _, statErr := os.Lstat(destPath); statErr == nil {
+		// Arquivo já existe - aplicar estratégia de conflito
+		logger.Debug("Destination file already exists, applying conflict strategy",
+			"file", filename,
+			"strategy", rule.ConflictStrategy)
+		return handleConflict(destPath, rule, logger)
+	}
+
+	return destPath, nil
+}
+
+// destinationPath retorna o caminho do arquivo em destDir antes da estratégia de conflito
+// (destName ou o nome de origem, com a sanitização da regra)
+func destinationPath(sourcePath, destDir, destName string, rule *config.Rule, logger *slog.Logger) string {
+	filename := destName
+	if filename == "" {
+		filename = filepath.Base(sourcePath)
+	}
+	return filepath.Join(destDir, sanitizeName(filename, rule.SanitizePolicy(destDir), logger))
+}
+
+// sanitizeName aplica a política sanitize ao nome do arquivo no destino
+func sanitizeName(filename, policy string, logger *slog.Logger) string {
+	sanitized := naming.Sanitize(filename, policy)
+	if sanitized != filename {
+		logger.Debug("File name sanitized", "original", filename, "sanitized", sanitized, "policy", policy)
+	}
+	return sanitized
 }
 
-// handleConflict aplica a estratégia de conflito e retorna o novo destPath
-func handleConflict(destPath, strategy string, logger *slog.Logger) (string, error) {
+// handleConflict aplica a estratégia de conflito da regra e retorna o novo destPath
+func handleConflict(destPath string, rule *config.Rule, logger *slog.Logger) (string, error) {
 	filename := filepath.Base(destPath)
+	strategy := rule.ConflictStrategy
+	metrics.Conflicts.Inc(rule.Monitor, rule.Name, strategy)
 
 	switch strategy {
 	case "overwrite":
@@ -105,11 +168,27 @@ func handleConflict(destPath, strategy string, logger *slog.Logger) (string, err
 		)
 		return newDestPath, nil
 
+	case "skip":
+		// Manter o arquivo existente e deixar o novo na origem
+		logger.Info("Destination file already exists, skipping", "file", filename, "destination", filepath.Dir(destPath))
+		return "", nil
+
 	default:
-		return "", fmt.Errorf("unknown conflict strategy: %s (use 'rename' or 'overwrite')", strategy)
+		return "", fmt.Errorf("unknown conflict strategy: %s (use 'rename', 'overwrite' or 'skip')", strategy)
 	}
 }
 
+// maxUniqueNames é quantos nomes com contador são tentados antes do nome com timestamp
+const maxUniqueNames = 1000
+
+// uniqueName retorna o nome com contador usado pela estratégia rename
+// Exemplo: document.pdf, 1 -> document_1.pdf
+func uniqueName(destPath string, counter int) string {
+	ext := filepath.Ext(destPath)
+	nameWithoutExt := strings.TrimSuffix(filepath.Base(destPath), ext)
+	return filepath.Join(filepath.Dir(destPath), fmt.Sprintf("%s_%d%s", nameWithoutExt, counter, ext))
+}
+
 // generateUniqueName gera um nome único para o arquivo adicionando um contador
 // Exemplo: document.pdf -> document_1.pdf -> document_2.pdf
 func generateUniqueName(destPath string) string {
@@ -119,8 +198,7 @@ func generateUniqueName(destPath string) string {
 
 	counter := 1
 	for {
-		newName := fmt.Sprintf("%s_%d%s", nameWithoutExt, counter, ext)
-		newPath := filepath.Join(dir, newName)
+		newPath := uniqueName(destPath, counter)
 
 		// Verificar se esse nome já existe
 		if _, err := os.Stat(newPath); os.IsNotExist(err) {
@@ -130,7 +208,7 @@ func generateUniqueName(destPath string) string {
 		counter++
 
 		// Segurança: limitar tentativas
-		if counter > 1000 {
+		if counter > maxUniqueNames {
 			// Usar timestamp como fallback
 			timestamp := time.Now().Format("20060102_150405")
 			newName := fmt.Sprintf("%s_%s%s", nameWithoutExt, timestamp, ext)
@@ -139,43 +217,196 @@ func generateUniqueName(destPath string) string {
 	}
 }
 
+// copyVerified copia o arquivo para um temporário oculto no diretório de destino,
+// verifica tamanho e SHA-256 contra a origem, sincroniza arquivo e diretório
+// e só então renomeia atomicamente para o nome final
+// Assim um crash nunca deixa um arquivo truncado com o nome definitivo
+// Retorna o SHA-256 verificado do arquivo
+// Com ctx cancelado (shutdown) a cópia não começa, ou é descartada antes do rename para o nome final
+func copyVerified(ctx context.Context, sourcePath, destPath string, rule *config.Rule, logger *slog.Logger) (string, error) {
+	destDir := filepath.Dir(destPath)
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("copy not started: %w", err)
+	}
+
+	// Recusar antes de começar se o volume de destino não comporta o arquivo
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source file: %w", err)
+	}
+	if err := checkFreeSpace(destDir, sourceInfo.Size(), logger); err != nil {
+		return "", err
+	}
+	// Data de acesso lida antes da cópia, que pode atualizá-la ao ler a origem
+	sourceAtime := accessTime(sourcePath, sourceInfo)
+
+	tmpFile, err := os.CreateTemp(destDir, TempFilePrefix+"*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+
+	// Remover o temporário em qualquer falha
+	success := false
+	defer func() {
+		if !success {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	start := time.Now()
+	method, err := copyFile(sourcePath, tmpPath)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("File data copied",
+		"file", filepath.Base(sourcePath),
+		"method", method,
+		"size", sourceInfo.Size(),
+		"duration", time.Since(start).String(),
+	)
+
+	// Permissões e metadados configurados na regra (antes do rename, para o arquivo já aparecer completo)
+	preserveMetadata(sourcePath, tmpPath, rule.Preserve, logger)
+
+	// Verificar a cópia antes de torná-la visível
+	sourceHash, sourceSize, err := HashFile(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash source file: %w", err)
+	}
+	copyHash, copySize, err := HashFile(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash copied file: %w", err)
+	}
+	if sourceSize != copySize {
+		return "", fmt.Errorf("%w: size mismatch (source %d bytes, copy %d bytes)", ErrVerificationFailed, sourceSize, copySize)
+	}
+	if sourceHash != copyHash {
+		return "", fmt.Errorf("%w: SHA-256 mismatch (source %s, copy %s)", ErrVerificationFailed, sourceHash, copyHash)
+	}
+	logger.Debug("Copy verified", "file", filepath.Base(sourcePath), "size", copySize, "sha256", copyHash)
+
+	// Datas por último: a leitura da verificação pode ter alterado o atime da cópia
+	preserveTimes(sourcePath, tmpPath, sourceAtime, sourceInfo.ModTime(), rule.Preserve, logger)
+
+	// Último ponto em que o shutdown pode interromper: depois do rename a cópia já está no destino
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("copy interrupted: %w", err)
+	}
+
+	// Renomear atomicamente para o nome final
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return "", fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+	success = true
+
+	// Persistir a entrada do diretório (rename) no disco
+	if err := syncDir(destDir); err != nil {
+		logger.Warn("Failed to sync destination directory", "path", destDir, "error", err)
+	}
+
+	metrics.BytesCopied.Add(float64(copySize), rule.Monitor, rule.Name)
+	return copyHash, nil
+}
+
+// syncDir executa fsync em um diretório para persistir criações e renomeações
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// RecoverTempFiles remove arquivos temporários órfãos deixados por cópias interrompidas
+// (ex: crash durante um move entre volumes). Deve ser chamado na inicialização
+func RecoverTempFiles(dirs []string, logger *slog.Logger) int {
+	removed := 0
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.Warn("Failed to scan directory for temporary files", "path", dir, "error", err)
+			}
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), TempFilePrefix) {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if err := os.Remove(path); err != nil {
+				logger.Warn("Failed to remove orphan temporary file", "file", path, "error", err)
+				continue
+			}
+			logger.Info("Removed orphan temporary file from interrupted copy", "file", path)
+			removed++
+		}
+	}
+
+	return removed
+}
+
 // copyFile copia um arquivo do source para destination
-func copyFile(sourcePath, destPath string) error {
+// Retorna o método usado na cópia (reflink, copy_file_range, sendfile ou buffered)
+func copyFile(sourcePath, destPath string) (string, error) {
 	// Abrir arquivo fonte
 	sourceFile, err := os.Open(sourcePath)
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+		return "", fmt.Errorf("failed to open source file: %w", err)
 	}
 	defer sourceFile.Close()
 
+	sourceInfo, err := sourceFile.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source file: %w", err)
+	}
+
 	// Criar arquivo destino
 	destFile, err := os.Create(destPath)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return "", fmt.Errorf("failed to create destination file: %w", err)
 	}
 	defer destFile.Close()
 
-	// Copiar conteúdo
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
+	// Copiar conteúdo (usando o caminho mais rápido disponível na plataforma)
+	method, err := copyContents(sourceFile, destFile, sourceInfo.Size())
+	if err != nil {
 		// Se a cópia falhar, tentar remover arquivo de destino parcial
 		destFile.Close()
 		os.Remove(destPath)
-		return fmt.Errorf("failed to copy file content: %w", err)
+		return "", fmt.Errorf("failed to copy file content: %w", err)
 	}
 
 	// Sincronizar para garantir que dados foram escritos no disco
 	if err := destFile.Sync(); err != nil {
-		return fmt.Errorf("failed to sync destination file: %w", err)
+		return "", fmt.Errorf("failed to sync destination file: %w", err)
 	}
 
-	// Copiar permissões do arquivo original
-	sourceInfo, err := sourceFile.Stat()
-	if err == nil {
-		if err := os.Chmod(destPath, sourceInfo.Mode()); err != nil {
-			// Não é crítico, apenas logar
-			// logger não está disponível aqui, então ignoramos
-		}
-	}
+	return method, nil
+}
 
-	return nil
+// copyBuffered copia o conteúdo com um buffer em espaço de usuário (fallback universal)
+// Os wrappers impedem que io.Copy delegue para os caminhos otimizados de *os.File
+func copyBuffered(src, dst *os.File) error {
+	buf := make([]byte, copyBufferSize)
+	_, err := io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, buf)
+	return err
+}
+
+// resetCopy volta os arquivos ao estado inicial para tentar outro método de cópia
+func resetCopy(src, dst *os.File) error {
+	if err := dst.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := dst.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := src.Seek(0, io.SeekStart)
+	return err
 }
@@ -0,0 +1,505 @@
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/naming"
+)
+
+// Tipos de etapa de pipeline
+const (
+	StepRename   = "rename"   // Renomeia o arquivo (antes do move, define o nome usado pelo move)
+	StepMove     = "move"     // Move o arquivo para outro diretório
+	StepCopy     = "copy"     // Copia o arquivo (o pipeline continua com o original)
+	StepCompress = "compress" // Compacta o arquivo (gzip ou zip)
+	StepChecksum = "checksum" // Calcula o SHA-256 e grava um arquivo .sha256 ao lado
+	StepExec     = "exec"     // Executa um comando externo
+	StepNotify   = "notify"   // Envia um POST JSON para uma URL
+)
+
+// ActionPipeline identifica no Result que a regra foi executada por um pipeline
+const ActionPipeline = "pipeline"
+
+// Timeouts padrão das etapas que dependem de processos ou serviços externos
+const (
+	defaultExecTimeout   = 60 * time.Second
+	defaultNotifyTimeout = 10 * time.Second
+)
+
+// PipelineContext é o contexto compartilhado entre as etapas de um pipeline
+// Cada etapa lê CurrentPath (saída da etapa anterior) e pode atualizá-lo
+type PipelineContext struct {
+	Monitor      string
+	Rule         string
+	OriginalPath string
+	CurrentPath  string
+	PendingName  string   // Nome definido por um rename antes do move, aplicado pelo move (e pelas cópias)
+	Moved        bool     // O arquivo já saiu da pasta monitorada (etapa move)
+	Checksum     string   // Preenchido pela etapa checksum
+	Outputs      []string // Arquivos produzidos pelas etapas (destinos, cópias, sidecars)
+
+	rule        *config.Rule
+	destination string // Destino da etapa move, cujo manifesto registra o arquivo final
+}
+
+// templateData retorna os dados disponíveis nos templates das etapas: os mesmos do rename da
+// regra (ver DestinationName), mais os campos do pipeline
+func (c *PipelineContext) templateData() (map[string]any, error) {
+	name := filepath.Base(c.CurrentPath)
+	if c.PendingName != "" {
+		name = c.PendingName
+	}
+
+	data, err := templateData(c.CurrentPath, name, c.Monitor, c.rule)
+	if err != nil {
+		return nil, err
+	}
+	data["Path"] = c.CurrentPath
+	data["Dir"] = filepath.Dir(c.CurrentPath)
+	data["Original"] = c.OriginalPath
+	data["Checksum"] = c.Checksum
+	data["Outputs"] = c.Outputs
+	return data, nil
+}
+
+// expand aplica os dados do contexto a um template de etapa
+func (c *PipelineContext) expand(text string) (string, error) {
+	tmpl, err := naming.Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template '%s': %w", text, err)
+	}
+
+	data, err := c.templateData()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to expand template '%s': %w", text, err)
+	}
+	return buf.String(), nil
+}
+
+// RunPipeline executa as etapas do pipeline da regra em ordem
+// A primeira etapa que falhar interrompe o pipeline e o arquivo é enviado
+// para o failure_destination da regra (se configurado)
+// O cancelamento de jobCtx (shutdown) interrompe o pipeline enquanto o arquivo está na origem;
+// depois da etapa move as etapas restantes são concluídas, para não deixar o arquivo pela metade
+func RunPipeline(jobCtx context.Context, sourcePath, monitor string, rule *config.Rule, logger *slog.Logger) (*Result, error) {
+	ctx := &PipelineContext{
+		Monitor:      monitor,
+		Rule:         rule.Name,
+		OriginalPath: sourcePath,
+		CurrentPath:  sourcePath,
+		rule:         rule,
+	}
+	result := &Result{Action: ActionPipeline}
+
+	for i := range rule.Pipeline {
+		step := &rule.Pipeline[i]
+
+		logger.Debug("Running pipeline step",
+			"file", filepath.Base(sourcePath),
+			"step", i+1,
+			"type", step.Type,
+			"current_path", ctx.CurrentPath,
+		)
+
+		stepCtx := jobCtx
+		if ctx.Moved {
+			stepCtx = context.WithoutCancel(jobCtx)
+		}
+		if err := stepCtx.Err(); err != nil {
+			result.Destinations = ctx.Outputs
+			return result, fmt.Errorf("pipeline interrupted before step %d (%s): %w", i+1, step.Type, err)
+		}
+
+		if err := runStep(stepCtx, ctx, step, rule, logger); err != nil {
+			err = fmt.Errorf("pipeline step %d (%s) failed: %w", i+1, step.Type, err)
+			// Interrompido pelo shutdown: o arquivo continua na origem, sem ir para o failure_destination
+			if stepCtx.Err() == nil {
+				handlePipelineFailure(jobCtx, ctx, rule, logger)
+			}
+			result.Destinations = ctx.Outputs
+			result.SHA256 = ctx.Checksum
+			return result, err
+		}
+	}
+
+	// O arquivo final do pipeline também é uma saída, se mudou de lugar
+	if ctx.CurrentPath != sourcePath {
+		ctx.Outputs = append(ctx.Outputs, ctx.CurrentPath)
+	}
+
+	// Registrado no manifesto só agora: etapas depois do move (compress, rename) trocam o arquivo
+	if ctx.destination != "" {
+		recordManifest(ctx.destination, ctx.CurrentPath, "", rule, logger)
+	}
+	result.Destinations = ctx.Outputs
+	result.SHA256 = ctx.Checksum
+
+	logger.Info("Pipeline completed",
+		"file", filepath.Base(sourcePath),
+		"rule", rule.Name,
+		"steps", len(rule.Pipeline),
+		"final_path", ctx.CurrentPath,
+	)
+
+	return result, nil
+}
+
+// handlePipelineFailure envia o arquivo para o failure_destination da regra
+func handlePipelineFailure(jobCtx context.Context, ctx *PipelineContext, rule *config.Rule, logger *slog.Logger) {
+	if rule.FailureDestination == "" {
+		return
+	}
+	if _, err := os.Stat(ctx.CurrentPath); err != nil {
+		logger.Warn("Failed file no longer exists, cannot route to failure destination", "file", ctx.CurrentPath)
+		return
+	}
+
+	// No destino de falhas nunca sobrescrever; ele não tem manifesto
+	failureRule := *rule
+	failureRule.ConflictStrategy = "rename"
+	failureRule.Manifest = ""
+
+	destPath, err := MoveFile(jobCtx, ctx.CurrentPath, rule.FailureDestination, "", &failureRule, logger)
+	if err != nil {
+		logger.Error("Failed to route file to failure destination",
+			"file", ctx.CurrentPath,
+			"failure_destination", rule.FailureDestination,
+			"error", err,
+		)
+		return
+	}
+
+	logger.Warn("File routed to failure destination", "file", filepath.Base(ctx.OriginalPath), "path", destPath)
+	ctx.CurrentPath = destPath
+}
+
+// runStep executa uma etapa do pipeline
+// jobCtx interrompe as cópias das etapas move e copy
+func runStep(jobCtx context.Context, ctx *PipelineContext, step *config.Step, rule *config.Rule, logger *slog.Logger) error {
+	// Etapas move/copy/rename podem ter estratégia de conflito e política sanitize próprias
+	stepRule := *rule
+	if step.ConflictStrategy != "" {
+		stepRule.ConflictStrategy = step.ConflictStrategy
+	}
+	if step.Sanitize != "" {
+		stepRule.Sanitize = step.Sanitize
+	}
+
+	// Etapas que gravam em outro diretório respeitam as cotas da regra e o espaço livre
+	// (as etapas move e copy correspondem às ações de mesmo nome)
+	if step.Type == StepMove || step.Type == StepCopy {
+		info, err := os.Stat(ctx.CurrentPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+		if err := preflight(ctx.CurrentPath, info.Size(), []string{step.Destination}, step.Type, rule, logger); err != nil {
+			return err
+		}
+	}
+
+	switch step.Type {
+	case StepRename:
+		return renameStep(ctx, step, &stepRule, logger)
+
+	case StepMove:
+		// O manifesto registra o arquivo final, ao fim do pipeline
+		stepRule.Manifest = ""
+		destPath, err := MoveFile(jobCtx, ctx.CurrentPath, step.Destination, ctx.PendingName, &stepRule, logger)
+		if err != nil {
+			return err
+		}
+		if destPath == "" {
+			return fmt.Errorf("file was not moved to %s", step.Destination)
+		}
+		ctx.CurrentPath = destPath
+		ctx.PendingName = ""
+		ctx.Moved = true
+		ctx.destination = step.Destination
+		return nil
+
+	case StepCopy:
+		destPath, err := CopyFile(jobCtx, ctx.CurrentPath, step.Destination, ctx.PendingName, &stepRule, logger)
+		if err != nil {
+			return err
+		}
+		if destPath != "" {
+			ctx.Outputs = append(ctx.Outputs, destPath)
+		}
+		return nil
+
+	case StepCompress:
+		return compressStep(ctx, step, &stepRule, logger)
+
+	case StepChecksum:
+		return checksumStep(ctx, logger)
+
+	case StepExec:
+		return execStep(ctx, step, logger)
+
+	case StepNotify:
+		return notifyStep(ctx, step, logger)
+
+	default:
+		return fmt.Errorf("unknown step type: %s", step.Type)
+	}
+}
+
+// renameStep renomeia o arquivo no próprio diretório usando o template "to"
+// Antes do move o arquivo ainda está na pasta monitorada: renomeá-lo lá geraria um novo evento
+// e o pipeline rodaria de novo, então a etapa só define o nome que o move vai usar
+func renameStep(ctx *PipelineContext, step *config.Step, rule *config.Rule, logger *slog.Logger) error {
+	newName, err := ctx.expand(step.To)
+	if err != nil {
+		return err
+	}
+	if err := naming.ValidateComponent(newName); err != nil {
+		return err
+	}
+	newName = sanitizeName(newName, rule.Sanitize, logger)
+
+	if !ctx.Moved {
+		logger.Debug("File will be renamed when moved", "file", filepath.Base(ctx.CurrentPath), "to", newName)
+		ctx.PendingName = newName
+		return nil
+	}
+
+	dir := filepath.Dir(ctx.CurrentPath)
+	destPath := filepath.Join(dir, newName)
+	if destPath == ctx.CurrentPath {
+		return nil
+	}
+
+	if _, err := os.Lstat(destPath); err == nil {
+		destPath, err = handleConflict(destPath, rule, logger)
+		if err != nil {
+			return err
+		}
+		if destPath == "" {
+			return fmt.Errorf("file %s already exists", newName)
+		}
+	}
+
+	if err := os.Rename(ctx.CurrentPath, destPath); err != nil {
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+
+	logger.Info("File renamed", "from", filepath.Base(ctx.CurrentPath), "to", filepath.Base(destPath))
+	ctx.CurrentPath = destPath
+	return nil
+}
+
+// compressStep compacta o arquivo atual (gzip por padrão) e continua com o arquivo compactado
+func compressStep(ctx *PipelineContext, step *config.Step, rule *config.Rule, logger *slog.Logger) error {
+	format := step.Format
+	if format == "" {
+		format = "gzip"
+	}
+
+	ext := ".gz"
+	if format == "zip" {
+		ext = ".zip"
+	}
+	destPath := ctx.CurrentPath + ext
+
+	// Arquivo compactado já existente: estratégia de conflito da regra (ou da etapa)
+	if _, err := os.Lstat(destPath); err == nil {
+		name := filepath.Base(destPath)
+		destPath, err = handleConflict(destPath, rule, logger)
+		if err != nil {
+			return err
+		}
+		if destPath == "" {
+			return fmt.Errorf("file %s already exists", name)
+		}
+	}
+
+	source, err := os.Open(ctx.CurrentPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer source.Close()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(destPath), TempFilePrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath) // No-op após o rename
+
+	if err := compressTo(tmpFile, source, filepath.Base(ctx.CurrentPath), format); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to sync compressed file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close compressed file: %w", err)
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return fmt.Errorf("failed to rename compressed file: %w", err)
+	}
+
+	// Remover o original (exceto se keep_original)
+	if !step.KeepOriginal {
+		source.Close()
+		if err := os.Remove(ctx.CurrentPath); err != nil {
+			return fmt.Errorf("failed to remove original after compression: %w", err)
+		}
+	} else {
+		ctx.Outputs = append(ctx.Outputs, ctx.CurrentPath)
+	}
+
+	logger.Info("File compressed", "file", filepath.Base(ctx.CurrentPath), "format", format)
+	ctx.CurrentPath = destPath
+	return nil
+}
+
+// compressTo escreve o conteúdo compactado no formato pedido
+func compressTo(w io.Writer, r io.Reader, name, format string) error {
+	switch format {
+	case "gzip":
+		gz := gzip.NewWriter(w)
+		gz.Name = name
+		if _, err := io.Copy(gz, r); err != nil {
+			return fmt.Errorf("failed to compress file: %w", err)
+		}
+		return gz.Close()
+
+	case "zip":
+		zw := zip.NewWriter(w)
+		entry, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to create zip entry: %w", err)
+		}
+		if _, err := io.Copy(entry, r); err != nil {
+			return fmt.Errorf("failed to compress file: %w", err)
+		}
+		return zw.Close()
+
+	default:
+		return fmt.Errorf("unknown compression format: %s", format)
+	}
+}
+
+// checksumSuffix é a extensão do arquivo gravado pela etapa checksum ao lado do arquivo
+const checksumSuffix = ".sha256"
+
+// checksumStep calcula o SHA-256 do arquivo atual e grava "<arquivo>.sha256" no formato do sha256sum
+func checksumStep(ctx *PipelineContext, logger *slog.Logger) error {
+	hash, _, err := HashFile(ctx.CurrentPath)
+	if err != nil {
+		return err
+	}
+
+	sidecar := ctx.CurrentPath + checksumSuffix
+	line := fmt.Sprintf("%s  %s\n", hash, filepath.Base(ctx.CurrentPath))
+	if err := os.WriteFile(sidecar, []byte(line), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum file: %w", err)
+	}
+
+	logger.Debug("Checksum written", "file", filepath.Base(ctx.CurrentPath), "sha256", hash)
+	ctx.Checksum = hash
+	ctx.Outputs = append(ctx.Outputs, sidecar)
+	return nil
+}
+
+// execStep executa um comando externo; saída diferente de zero falha a etapa
+// Os argumentos aceitam templates e o comando recebe o contexto em variáveis GAA_*
+func execStep(ctx *PipelineContext, step *config.Step, logger *slog.Logger) error {
+	args := make([]string, 0, len(step.Command))
+	for _, arg := range step.Command {
+		expanded, err := ctx.expand(arg)
+		if err != nil {
+			return err
+		}
+		args = append(args, expanded)
+	}
+
+	timeout := parseStepTimeout(step.Timeout, defaultExecTimeout)
+	runCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"GAA_FILE="+ctx.CurrentPath,
+		"GAA_ORIGINAL="+ctx.OriginalPath,
+		"GAA_MONITOR="+ctx.Monitor,
+		"GAA_RULE="+ctx.Rule,
+		"GAA_CHECKSUM="+ctx.Checksum,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("command %s timed out after %s: %w", args[0], timeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		return fmt.Errorf("command %s failed: %w (output: %s)", args[0], err, strings.TrimSpace(string(output)))
+	}
+
+	logger.Debug("Command completed", "command", args[0], "output", strings.TrimSpace(string(output)))
+	return nil
+}
+
+// notifyStep envia o contexto do pipeline como JSON para a URL da etapa
+func notifyStep(ctx *PipelineContext, step *config.Step, logger *slog.Logger) error {
+	body, err := json.Marshal(map[string]any{
+		"monitor":  ctx.Monitor,
+		"rule":     ctx.Rule,
+		"original": ctx.OriginalPath,
+		"path":     ctx.CurrentPath,
+		"checksum": ctx.Checksum,
+		"outputs":  ctx.Outputs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	client := &http.Client{Timeout: parseStepTimeout(step.Timeout, defaultNotifyTimeout)}
+	resp, err := client.Post(step.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification rejected: %s", resp.Status)
+	}
+
+	logger.Debug("Notification sent", "url", step.URL, "status", resp.StatusCode)
+	return nil
+}
+
+// parseStepTimeout converte o timeout da etapa (já validado no config) ou usa o padrão
+func parseStepTimeout(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return fallback
+	}
+	return timeout
+}
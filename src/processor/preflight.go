@@ -0,0 +1,244 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gaa/file-organizer/src/config"
+)
+
+// Erros das verificações feitas antes de gravar no destino
+var (
+	ErrInsufficientSpace = errors.New("insufficient free space")
+	ErrQuotaExceeded     = errors.New("destination quota exceeded")
+
+	errSpaceUnsupported = errors.New("free space check not supported on this platform")
+)
+
+// CheckWritable verifica se é possível gravar em dir ou, se ele ainda não existe,
+// criá-lo a partir do ancestral existente mais próximo
+func CheckWritable(dir string) error {
+	path := dir
+	for {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) && filepath.Dir(path) != path {
+			path = filepath.Dir(path)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
+		}
+		if err := accessWritable(path); err != nil {
+			return fmt.Errorf("%s is not writable: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// checkFreeSpace verifica se o volume de destDir comporta mais size bytes
+// Se destDir ainda não existe, verifica o volume do ancestral existente mais próximo
+func checkFreeSpace(destDir string, size int64, logger *slog.Logger) error {
+	available, err := availableSpace(existingAncestor(destDir))
+	if err != nil {
+		// Sem a informação, a cópia é tentada mesmo assim
+		if !errors.Is(err, errSpaceUnsupported) {
+			logger.Debug("Failed to check free space", "path", destDir, "error", err)
+		}
+		return nil
+	}
+
+	if size > available {
+		return fmt.Errorf("%w on %s: need %d bytes, %d available", ErrInsufficientSpace, destDir, size, available)
+	}
+	return nil
+}
+
+// existingAncestor retorna dir, se existir, ou o ancestral existente mais próximo
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) || filepath.Dir(dir) == dir {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// checkQuota verifica se um arquivo de size bytes cabe nas cotas max_size/max_files da regra em destDir
+func checkQuota(destDir string, size int64, rule *config.Rule) error {
+	maxSize := rule.MaxSizeBytes()
+	if maxSize == 0 && rule.MaxFiles == 0 {
+		return nil
+	}
+
+	usedBytes, usedFiles, err := cachedUsage(destDir)
+	if err != nil {
+		return fmt.Errorf("failed to measure destination usage: %w", err)
+	}
+
+	if rule.MaxFiles > 0 && usedFiles+1 > rule.MaxFiles {
+		return fmt.Errorf("%w: %s already has %d files (max_files %d)", ErrQuotaExceeded, destDir, usedFiles, rule.MaxFiles)
+	}
+	if maxSize > 0 && usedBytes+size > maxSize {
+		return fmt.Errorf("%w: %s would use %d bytes (max_size %s)", ErrQuotaExceeded, destDir, usedBytes+size, rule.MaxSize)
+	}
+	return nil
+}
+
+// usageRefresh é o intervalo após o qual o uso de um destino é medido de novo, para incluir
+// arquivos gravados ou removidos por fora do organizador
+const usageRefresh = 5 * time.Minute
+
+// destinationUsage é o uso medido de um destino, somado aos arquivos colocados desde a medição
+type destinationUsage struct {
+	bytes    int64
+	files    int
+	measured time.Time
+}
+
+// Uso dos destinos com cota, por diretório
+var (
+	usageMu    sync.Mutex
+	usageCache = make(map[string]*destinationUsage)
+)
+
+// cachedUsage retorna o uso de destDir, percorrendo o diretório apenas na primeira consulta
+// e depois de usageRefresh; a varredura é feita fora do lock para não bloquear outros destinos
+func cachedUsage(destDir string) (int64, int, error) {
+	key := filepath.Clean(destDir)
+
+	usageMu.Lock()
+	usage := usageCache[key]
+	if usage != nil && time.Since(usage.measured) < usageRefresh {
+		bytes, files := usage.bytes, usage.files
+		usageMu.Unlock()
+		return bytes, files, nil
+	}
+	usageMu.Unlock()
+
+	measured := time.Now()
+	bytes, files, err := directoryUsage(destDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	// Outra varredura mais recente pode ter sido publicada enquanto esta rodava
+	if current := usageCache[key]; current != nil && current.measured.After(measured) {
+		return current.bytes, current.files, nil
+	}
+	usageCache[key] = &destinationUsage{bytes: bytes, files: files, measured: measured}
+	return bytes, files, nil
+}
+
+// trackPlacement soma ao uso em cache de destDir o arquivo colocado em destPath
+// Arquivos substituídos (overwrite) continuam contados até a próxima varredura
+func trackPlacement(destDir, destPath string) {
+	info, err := os.Lstat(destPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	if usage := usageCache[filepath.Clean(destDir)]; usage != nil {
+		usage.bytes += info.Size()
+		usage.files++
+	}
+}
+
+// directoryUsage soma o tamanho e a quantidade de arquivos regulares abaixo de dir
+// Temporários de cópias em andamento e índices não são contados
+func directoryUsage(dir string) (int64, int, error) {
+	var bytes int64
+	files := 0
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() || isOrganizerFile(entry.Name()) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil // Removido durante a varredura
+		}
+		bytes += info.Size()
+		files++
+		return nil
+	})
+
+	return bytes, files, err
+}
+
+// preflight verifica as cotas de todos os destinos e o espaço livre dos que vão receber uma cópia
+// do conteúdo antes de executar a ação, para não deixar a ação concluída em apenas parte dos destinos
+func preflight(sourcePath string, size int64, destinations []string, action string, rule *config.Rule, logger *slog.Logger) error {
+	for i, destDir := range destinations {
+		if err := checkQuota(destDir, size, rule); err != nil {
+			return err
+		}
+		if !writesContent(sourcePath, destDir, action, i == len(destinations)-1) {
+			continue
+		}
+		if err := checkFreeSpace(destDir, size, logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writesContent indica se a ação grava o conteúdo do arquivo em destDir
+// Links e o move para o último destino no mesmo volume (um rename) não ocupam espaço novo
+func writesContent(sourcePath, destDir, action string, last bool) bool {
+	switch action {
+	case ActionCopy:
+		return true
+	case ActionMove, ActionMoveAndLink:
+		return !last || !sameVolume(sourcePath, existingAncestor(destDir))
+	default:
+		return false
+	}
+}
+
+// sendToOverflow envia o arquivo recusado pelo destino para o overflow_destination da regra
+// Regras move e move_and_link movem o arquivo; copy envia uma cópia e mantém o original
+func sendToOverflow(ctx context.Context, sourcePath, destName string, rule *config.Rule, reason error, logger *slog.Logger) (string, error) {
+	// Nunca sobrescrever no destino de overflow, que não tem manifesto
+	overflowRule := *rule
+	overflowRule.ConflictStrategy = "rename"
+	overflowRule.Manifest = ""
+
+	var destPath string
+	var err error
+	if rule.ActionName() == ActionCopy {
+		destPath, err = CopyFile(ctx, sourcePath, rule.OverflowDestination, destName, &overflowRule, logger)
+	} else {
+		destPath, err = MoveFile(ctx, sourcePath, rule.OverflowDestination, destName, &overflowRule, logger)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%v; failed to send file to overflow destination: %w", reason, err)
+	}
+
+	logger.Warn("File sent to overflow destination",
+		"file", filepath.Base(sourcePath),
+		"overflow_destination", rule.OverflowDestination,
+		"reason", reason.Error(),
+	)
+	return destPath, nil
+}
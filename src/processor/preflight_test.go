@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWritesContent(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("volumes are not compared on this platform")
+	}
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.pdf")
+	if err := os.WriteFile(source, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Destino ainda inexistente, no mesmo volume da origem
+	destDir := filepath.Join(dir, "out", "2026")
+
+	tests := []struct {
+		action string
+		last   bool
+		want   bool
+	}{
+		{ActionCopy, true, true},
+		{ActionMove, false, true},
+		{ActionMove, true, false},
+		{ActionMoveAndLink, true, false},
+		{ActionHardlink, true, false},
+		{ActionSymlink, true, false},
+		{ActionDelete, true, false},
+	}
+	for _, tt := range tests {
+		if got := writesContent(source, destDir, tt.action, tt.last); got != tt.want {
+			t.Errorf("writesContent(%s, last=%v) = %v, want %v", tt.action, tt.last, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/naming"
+)
+
+// DestinationName calcula o nome do arquivo no destino aplicando o template rename da regra
+// Retorna o nome original se a regra não define rename
+func DestinationName(sourcePath, monitor string, rule *config.Rule) (string, error) {
+	filename := filepath.Base(sourcePath)
+	if rule.Rename == "" {
+		return filename, nil
+	}
+
+	data, err := templateData(sourcePath, filename, monitor, rule)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := naming.Parse(rule.Rename)
+	if err != nil {
+		return "", fmt.Errorf("invalid rename template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to expand rename template: %w", err)
+	}
+
+	newName := strings.TrimSpace(buf.String())
+	if err := naming.ValidateComponent(newName); err != nil {
+		return "", err
+	}
+	return newName, nil
+}
+
+// templateData monta os dados comuns aos templates de nome (rename da regra e etapas do pipeline)
+// filename é o nome atual do arquivo; path é onde ele está (para ModTime)
+func templateData(path, filename, monitor string, rule *config.Rule) (map[string]any, error) {
+	ext := filepath.Ext(filename)
+	name := strings.TrimSuffix(filename, ext)
+
+	data := map[string]any{
+		"Filename": filename,
+		"Name":     name,
+		"Ext":      ext,
+		"Rule":     rule.Name,
+		"Monitor":  monitor,
+		"Now":      time.Now(),
+		"ModTime":  time.Now(),
+		"Groups":   []string{},
+		"Captures": map[string]string{},
+	}
+	if info, err := os.Stat(path); err == nil {
+		data["ModTime"] = info.ModTime()
+	}
+
+	// Capturas da rename_regex, aplicada ao nome sem extensão
+	if rule.RenameRegex != "" {
+		re, err := regexp.Compile(rule.RenameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename_regex: %w", err)
+		}
+		groups := re.FindStringSubmatch(name)
+		if groups == nil {
+			return nil, fmt.Errorf("rename_regex '%s' does not match '%s'", rule.RenameRegex, name)
+		}
+
+		captures := make(map[string]string)
+		for i, captureName := range re.SubexpNames() {
+			if captureName != "" {
+				captures[captureName] = groups[i]
+			}
+		}
+		data["Groups"] = groups
+		data["Captures"] = captures
+	}
+
+	return data, nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package processor
+
+// availableSpace não é suportado nesta plataforma
+func availableSpace(dir string) (int64, error) {
+	return 0, errSpaceUnsupported
+}
+
+// sameVolume não é verificado nesta plataforma: considera volumes diferentes
+func sameVolume(a, b string) bool {
+	return false
+}
+
+// accessWritable não é verificado nesta plataforma: a falha aparece ao gravar
+func accessWritable(dir string) error {
+	return nil
+}
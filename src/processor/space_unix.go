@@ -0,0 +1,28 @@
+//go:build linux || darwin
+
+package processor
+
+import "golang.org/x/sys/unix"
+
+// availableSpace retorna quantos bytes estão disponíveis para usuários comuns no volume de dir
+func availableSpace(dir string) (int64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// sameVolume indica se os caminhos existentes a e b estão no mesmo volume (um rename entre eles não copia dados)
+func sameVolume(a, b string) bool {
+	var statA, statB unix.Stat_t
+	if unix.Stat(a, &statA) != nil || unix.Stat(b, &statB) != nil {
+		return false
+	}
+	return statA.Dev == statB.Dev
+}
+
+// accessWritable verifica se o processo pode criar arquivos em dir (permissões e volumes somente leitura)
+func accessWritable(dir string) error {
+	return unix.Access(dir, unix.W_OK|unix.X_OK)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gaa/file-organizer/src/watcher"
+)
+
+// Report é a resposta do endpoint /status
+type Report struct {
+	Healthy   bool             `json:"healthy"` // Nenhum monitor degradado
+	Ready     bool             `json:"ready"`   // Saudável e todos os monitores monitorando o source_path
+	StartedAt time.Time        `json:"started_at"`
+	Monitors  []watcher.Status `json:"monitors"`
+}
+
+// HandleHealth registra /healthz, /readyz e /status para os watchers informados
+//
+//	/healthz: 200 enquanto nenhum watcher estiver degradado (loop encerrado, destino sem gravação)
+//	/readyz:  200 quando, além disso, todos os watchers estão em execução (nenhum aguardando o source_path)
+//	/status:  estado de cada watcher em JSON
+func (s *Server) HandleHealth(watchers []*watcher.FileWatcher) {
+	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		report := s.report(watchers)
+		writeCheck(w, report.Healthy, report)
+	})
+
+	s.mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := s.report(watchers)
+		writeCheck(w, report.Ready, report)
+	})
+
+	s.mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(s.report(watchers))
+	})
+}
+
+// report coleta o estado atual de todos os watchers
+func (s *Server) report(watchers []*watcher.FileWatcher) Report {
+	report := Report{
+		Healthy:   true,
+		Ready:     true,
+		StartedAt: s.started,
+		Monitors:  make([]watcher.Status, 0, len(watchers)),
+	}
+
+	for _, fw := range watchers {
+		status := fw.Status()
+		if !status.Healthy {
+			report.Healthy = false
+		}
+		if status.State != watcher.StateRunning {
+			report.Ready = false
+		}
+		report.Monitors = append(report.Monitors, status)
+	}
+
+	return report
+}
+
+// writeCheck responde "ok" (200) ou a lista de monitores com problema (503)
+func writeCheck(w http.ResponseWriter, ok bool, report Report) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if ok {
+		fmt.Fprintln(w, "ok")
+		return
+	}
+
+	w.WriteHeader(http.StatusServiceUnavailable)
+	for _, status := range report.Monitors {
+		if status.State == watcher.StateRunning {
+			continue
+		}
+		detail := ""
+		if len(status.Problems) > 0 {
+			detail = ": " + strings.Join(status.Problems, "; ")
+		}
+		fmt.Fprintf(w, "%s %s%s\n", status.Monitor, status.State, detail)
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"gaa/file-organizer/src/metrics"
+)
+
+// shutdownTimeout é o tempo dado às requisições em andamento ao parar o servidor
+const shutdownTimeout = 5 * time.Second
+
+// Server é o servidor HTTP opcional do daemon (métricas em /metrics, estado dos monitores)
+type Server struct {
+	addr    string
+	mux     *http.ServeMux
+	http    *http.Server
+	logger  *slog.Logger
+	started time.Time
+}
+
+// New cria o servidor para o endereço addr (ex: "127.0.0.1:9090") com o endpoint /metrics
+func New(addr string, logger *slog.Logger) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &Server{
+		addr: addr,
+		mux:  mux,
+		http: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		logger:  logger,
+		started: time.Now(),
+	}
+}
+
+// Start abre a porta e passa a atender em segundo plano
+// Erros de bind (porta em uso, endereço inválido) são retornados imediatamente
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+
+	s.logger.Info("HTTP server listening", "address", listener.Addr().String())
+
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("HTTP server stopped unexpectedly", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Stop encerra o servidor, aguardando as requisições em andamento
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.logger.Error("Error stopping HTTP server", "error", err)
+	}
+}
@@ -0,0 +1,187 @@
+package systemd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// journalSocket é o socket do protocolo nativo do journald
+const journalSocket = "/run/systemd/journal/socket"
+
+// maxFieldName é o tamanho máximo de um nome de campo aceito pelo journald
+const maxFieldName = 64
+
+// journalHandler é um slog.Handler que envia cada registro ao journald como campos estruturados
+// (MESSAGE, PRIORITY, SYSLOG_IDENTIFIER e um campo por atributo: file=... vira FILE=...)
+type journalHandler struct {
+	conn       *net.UnixConn
+	level      slog.Leveler
+	identifier string
+	prefix     string // Grupos abertos com WithGroup ("GROUP_")
+	fields     []byte // Campos já codificados dos atributos de WithAttrs
+}
+
+// NewJournalHandler conecta ao journald e retorna um handler com o nível e o SYSLOG_IDENTIFIER informados
+func NewJournalHandler(identifier string, level slog.Leveler) (slog.Handler, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journalHandler{conn: conn, level: level, identifier: identifier}, nil
+}
+
+// Enabled implementa slog.Handler
+func (h *journalHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle implementa slog.Handler: um datagrama por registro
+func (h *journalHandler) Handle(ctx context.Context, record slog.Record) error {
+	var buf bytes.Buffer
+	writeField(&buf, "MESSAGE", record.Message)
+	writeField(&buf, "PRIORITY", strconv.Itoa(priority(record.Level)))
+	writeField(&buf, "SYSLOG_IDENTIFIER", h.identifier)
+	buf.Write(h.fields)
+	record.Attrs(func(attr slog.Attr) bool {
+		appendAttr(&buf, h.prefix, attr)
+		return true
+	})
+
+	return h.send(buf.Bytes())
+}
+
+// WithAttrs implementa slog.Handler
+func (h *journalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var buf bytes.Buffer
+	buf.Write(h.fields)
+	for _, attr := range attrs {
+		appendAttr(&buf, h.prefix, attr)
+	}
+
+	clone := *h
+	clone.fields = buf.Bytes()
+	return &clone
+}
+
+// WithGroup implementa slog.Handler
+func (h *journalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "_"
+	return &clone
+}
+
+// send envia o registro; registros maiores que um datagrama vão em um memfd (como faz o sd_journal_send)
+func (h *journalHandler) send(data []byte) error {
+	_, err := h.conn.Write(data)
+	if err == nil || (!errors.Is(err, unix.EMSGSIZE) && !errors.Is(err, unix.ENOBUFS)) {
+		return err
+	}
+
+	fd, err := unix.MemfdCreate("journal-message", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("failed to create memfd for large journal message: %w", err)
+	}
+	file := os.NewFile(uintptr(fd), "journal-message")
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	// O journald só aceita memfds selados
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return fmt.Errorf("failed to seal memfd: %w", err)
+	}
+
+	_, _, err = h.conn.WriteMsgUnix(nil, unix.UnixRights(fd), nil)
+	return err
+}
+
+// appendAttr codifica o atributo (e os atributos de grupos, com o nome do grupo como prefixo)
+func appendAttr(buf *bytes.Buffer, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "_"
+		}
+		for _, member := range attr.Value.Group() {
+			appendAttr(buf, prefix, member)
+		}
+		return
+	}
+
+	var value string
+	switch attr.Value.Kind() {
+	case slog.KindTime:
+		value = attr.Value.Time().Format(time.RFC3339Nano)
+	default:
+		value = attr.Value.String()
+	}
+	writeField(buf, fieldName(prefix+attr.Key), value)
+}
+
+// writeField codifica um campo: "NOME=valor\n", ou o formato binário se o valor tiver quebras de linha
+func writeField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// fieldName converte a chave do atributo em um nome de campo válido do journald
+// (maiúsculas, dígitos e "_", sem começar com "_" ou dígito, até 64 caracteres)
+func fieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		name = append([]byte("X_"), name...)
+	}
+	if len(name) > maxFieldName {
+		name = name[:maxFieldName]
+	}
+	return string(name)
+}
+
+// priority converte o nível do slog para a prioridade do syslog
+func priority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
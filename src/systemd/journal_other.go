@@ -0,0 +1,13 @@
+//go:build !linux
+
+package systemd
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// NewJournalHandler não é suportado fora do Linux
+func NewJournalHandler(identifier string, level slog.Leveler) (slog.Handler, error) {
+	return nil, errors.New("journald is only available on Linux")
+}
@@ -0,0 +1,62 @@
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Mensagens do protocolo sd_notify
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Enabled indica se o processo foi iniciado pelo systemd com Type=notify (NOTIFY_SOCKET definido)
+func Enabled() bool {
+	return os.Getenv("NOTIFY_SOCKET") != ""
+}
+
+// Notify envia uma ou mais linhas "CHAVE=valor" ao systemd
+// Sem NOTIFY_SOCKET (fora do systemd ou Type diferente de notify) não faz nada
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// Sockets abstratos começam com "@", que o pacote net já converte para o byte nulo
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	return nil
+}
+
+// Status envia o texto exibido por "systemctl status"
+func Status(text string) error {
+	return Notify("STATUS=" + text)
+}
+
+// WatchdogInterval retorna o WatchdogSec= configurado na unit (0 se o watchdog estiver desativado)
+// Os pings devem ser enviados com pelo menos o dobro dessa frequência
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	// WATCHDOG_PID, quando presente, indica qual processo deve enviar os pings
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,137 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gaa/file-organizer/src/processor"
+)
+
+// filterPaused é o motivo de descarte de arquivos de um monitor pausado (label reason)
+const filterPaused = "paused"
+
+// Name retorna o nome do monitor
+func (fw *FileWatcher) Name() string {
+	return fw.config.Name
+}
+
+// Pause suspende o envio de novos arquivos para o worker pool
+// Eventos continuam sendo recebidos; os arquivos que chegarem são processados pelo rescan do Resume
+func (fw *FileWatcher) Pause() {
+	fw.stateMu.Lock()
+	fw.paused = true
+	fw.stateMu.Unlock()
+
+	fw.logger.Info("Monitor paused", "monitor", fw.config.Name)
+}
+
+// Resume volta a enviar arquivos ao worker pool e agenda um rescan para os arquivos
+// que chegaram durante a pausa
+func (fw *FileWatcher) Resume() {
+	fw.stateMu.Lock()
+	wasPaused := fw.paused
+	fw.paused = false
+	fw.stateMu.Unlock()
+
+	fw.logger.Info("Monitor resumed", "monitor", fw.config.Name)
+	if wasPaused {
+		fw.requestRescan("monitor resumed")
+	}
+}
+
+// Paused indica se o monitor está pausado
+func (fw *FileWatcher) Paused() bool {
+	fw.stateMu.Lock()
+	defer fw.stateMu.Unlock()
+	return fw.paused
+}
+
+// Rescan percorre o source_path imediatamente, sem aguardar o intervalo mínimo entre rescans
+// Recusa o pedido se uma varredura do monitor já está em andamento
+func (fw *FileWatcher) Rescan() error {
+	if state := fw.State(); state != StateRunning {
+		return fmt.Errorf("monitor %s is %s, not running", fw.config.Name, state)
+	}
+
+	fw.rescanMu.Lock()
+	running := fw.rescanRunning
+	fw.rescanMu.Unlock()
+	if running {
+		return fmt.Errorf("monitor %s is already being rescanned", fw.config.Name)
+	}
+	go fw.rescan("requested via control socket")
+	return nil
+}
+
+// Reprocess envia um arquivo do source_path para processamento, ignorando os filtros de
+// arquivos ocultos, temporários e travados (mas não a pausa do monitor)
+func (fw *FileWatcher) Reprocess(path string) error {
+	if fw.Paused() {
+		return fmt.Errorf("monitor %s is paused", fw.config.Name)
+	}
+
+	if fw.isDestinationPath(path) {
+		return fmt.Errorf("%s is inside a destination of monitor %s", path, fw.config.Name)
+	}
+	if !fw.Contains(path) {
+		return fmt.Errorf("%s is not inside %s", path, fw.config.SourcePath)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if !fw.IsFileReady(path) {
+		return fmt.Errorf("%s is locked or not readable", path)
+	}
+
+	err = fw.workerPool.Submit(Job{
+		FilePath: path,
+		Monitor:  fw.config.Name,
+		Rules:    fw.config.Rules,
+	})
+	if err != nil {
+		return err
+	}
+	fw.logger.Info("File submitted for reprocessing", "monitor", fw.config.Name, "file", path)
+	return nil
+}
+
+// Restore reenvia um job salvo no último shutdown
+// Um move interrompido depois de a cópia chegar ao destino é concluído sem reenviar o arquivo
+func (fw *FileWatcher) Restore(path string) error {
+	if rule := processor.MatchRule(path, fw.config.Rules); rule != nil {
+		if destPath, err := processor.CompleteMove(path, fw.config.Name, rule, fw.logger); err != nil || destPath != "" {
+			return err
+		}
+	}
+	return fw.Reprocess(path)
+}
+
+// Contains indica se path está dentro do source_path do monitor e fora dos destinos das regras
+// (destinos dentro do source_path não são monitorados: reprocessar um arquivo lá o moveria de novo)
+func (fw *FileWatcher) Contains(path string) bool {
+	rel, err := filepath.Rel(fw.config.SourcePath, path)
+	inside := err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	return inside && !fw.isDestinationPath(path)
+}
+
+// Jobs retorna os jobs pendentes e em processamento do worker pool do monitor
+func (fw *FileWatcher) Jobs() (pending []JobInfo, running []JobInfo) {
+	return fw.workerPool.Jobs()
+}
+
+// Drain pausa o monitor e aguarda o worker pool terminar os jobs pendentes
+// Retorna false se timeout expirar antes (o monitor continua pausado)
+func (fw *FileWatcher) Drain(timeout time.Duration) bool {
+	fw.Pause()
+	drained := fw.workerPool.WaitIdle(timeout)
+	fw.logger.Info("Monitor drain finished", "monitor", fw.config.Name, "drained", drained)
+	return drained
+}
@@ -0,0 +1,108 @@
+package watcher
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// relativePath converte um path absoluto em relativo ao root (separado por "/")
+// Retorna false se o path estiver fora do root; o próprio root é ""
+func relativePath(root, target string) (string, bool) {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(target))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// shouldWatchDir decide se um diretório deve ser monitorado
+// watch indica se o diretório recebe um watch; descend indica se a busca deve continuar
+// nos subdiretórios (um diretório fora de include_dirs ainda pode conter um diretório incluído)
+func (fw *FileWatcher) shouldWatchDir(dir string) (watch bool, descend bool, reason string) {
+	rel, ok := relativePath(fw.config.SourcePath, dir)
+	if !ok {
+		return false, false, "outside source path"
+	}
+	if rel == "" {
+		return true, true, ""
+	}
+
+	if strings.HasPrefix(filepath.Base(dir), ".") {
+		return false, false, "hidden directory"
+	}
+	if fw.isDestinationPath(dir) {
+		return false, false, "destination path"
+	}
+	if fw.ignore.Match(dir, true) {
+		return false, false, "ignored"
+	}
+
+	depth := strings.Count(rel, "/") + 1
+	if fw.config.MaxDepth > 0 && depth > fw.config.MaxDepth {
+		return false, false, "max depth exceeded"
+	}
+
+	if matchesDirPatterns(rel, fw.config.ExcludeDirs) {
+		return false, false, "excluded"
+	}
+
+	if len(fw.config.IncludeDirs) > 0 && !isIncludedDir(rel, fw.config.IncludeDirs) {
+		return false, mayIncludeBelow(rel, fw.config.IncludeDirs), "not included"
+	}
+
+	return true, true, ""
+}
+
+// matchesDirPatterns verifica se o diretório corresponde a algum pattern
+// Patterns com "/" são comparados com o path relativo; os demais apenas com o nome
+func matchesDirPatterns(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		target := path.Base(rel)
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(strings.Trim(pattern, "/"), target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isIncludedDir verifica se o diretório ou algum diretório pai corresponde a include_dirs
+func isIncludedDir(rel string, patterns []string) bool {
+	for current := rel; current != "." && current != ""; current = path.Dir(current) {
+		if matchesDirPatterns(current, patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayIncludeBelow verifica se algum pattern de include_dirs com "/" pode corresponder
+// a um subdiretório de rel (ex: "Congonhas/*/2026" para rel "Congonhas")
+func mayIncludeBelow(rel string, patterns []string) bool {
+	relParts := strings.Split(rel, "/")
+
+	for _, pattern := range patterns {
+		patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+		if len(patternParts) <= len(relParts) {
+			continue
+		}
+
+		matches := true
+		for i, part := range relParts {
+			if ok, _ := path.Match(patternParts[i], part); !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
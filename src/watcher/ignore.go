@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gaa/file-organizer/src/ignore"
+)
+
+// ignoreFileName é o nome dos arquivos de ignore descobertos dentro da árvore monitorada
+const ignoreFileName = ".gaaignore"
+
+// IgnoreMatcher avalia paths contra patterns com a semântica do gitignore
+// Os patterns do config têm a menor prioridade, seguidos dos arquivos .gaaignore
+// do mais raso para o mais profundo (o último pattern que corresponder vence)
+type IgnoreMatcher struct {
+	root     string
+	patterns []ignore.Pattern
+
+	mu    sync.RWMutex
+	files map[string][]ignore.Pattern // dir relativo -> patterns do .gaaignore desse dir
+}
+
+// NewIgnoreMatcher cria um matcher para o root com os patterns definidos no config
+func NewIgnoreMatcher(root string, patterns []string) (*IgnoreMatcher, error) {
+	compiled, err := ignore.Compile("", patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IgnoreMatcher{
+		root:     filepath.Clean(root),
+		patterns: compiled,
+		files:    make(map[string][]ignore.Pattern),
+	}, nil
+}
+
+// LoadFile (re)carrega um arquivo .gaaignore
+// Se o arquivo não existir mais, os patterns dele são descartados
+func (m *IgnoreMatcher) LoadFile(path string) error {
+	dir, ok := m.relative(filepath.Dir(path))
+	if !ok {
+		return fmt.Errorf("ignore file outside of monitored tree: %s", path)
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		m.mu.Lock()
+		delete(m.files, dir)
+		m.mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ignore file: %w", err)
+	}
+
+	compiled, err := ignore.Compile(dir, lines)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	m.mu.Lock()
+	m.files[dir] = compiled
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Match verifica se o path deve ser ignorado
+// Assim como no git, um arquivo não pode ser re-incluído se um diretório pai foi ignorado
+func (m *IgnoreMatcher) Match(path string, isDir bool) bool {
+	rel, ok := m.relative(path)
+	if !ok || rel == "" {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Verificar diretórios pais primeiro
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchLocked(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+
+	return m.matchLocked(rel, isDir)
+}
+
+// matchLocked aplica todos os patterns aplicáveis ao path relativo (último match vence)
+func (m *IgnoreMatcher) matchLocked(rel string, isDir bool) bool {
+	ignored := false
+
+	apply := func(patterns []ignore.Pattern) {
+		for _, p := range patterns {
+			if p.Match(rel, isDir) {
+				ignored = !p.Negate
+			}
+		}
+	}
+
+	apply(m.patterns)
+
+	// Arquivos .gaaignore aplicáveis, do mais raso para o mais profundo
+	dirs := make([]string, 0, len(m.files))
+	for dir := range m.files {
+		if dir == "" || rel == dir || strings.HasPrefix(rel, dir+"/") {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return ignoreDepth(dirs[i]) < ignoreDepth(dirs[j])
+	})
+	for _, dir := range dirs {
+		apply(m.files[dir])
+	}
+
+	return ignored
+}
+
+// ignoreDepth retorna a profundidade de um diretório relativo ("" é o root)
+func ignoreDepth(dir string) int {
+	if dir == "" {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// relative converte um path absoluto em relativo ao root (separado por "/")
+func (m *IgnoreMatcher) relative(path string) (string, bool) {
+	return relativePath(m.root, path)
+}
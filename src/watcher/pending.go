@@ -0,0 +1,84 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PendingJob é um job não concluído no shutdown, salvo para ser reenviado na próxima execução
+type PendingJob struct {
+	Monitor   string `json:"monitor"`
+	File      string `json:"file"`
+	Abandoned bool   `json:"abandoned,omitempty"` // Estava em processamento quando o prazo expirou
+}
+
+// pendingFile é o conteúdo do arquivo de jobs pendentes (settings.pending_file)
+type pendingFile struct {
+	SavedAt time.Time    `json:"saved_at"`
+	Jobs    []PendingJob `json:"jobs"`
+}
+
+// SavePending grava em path os jobs pendentes e abandonados dos relatórios de shutdown
+// (substituição atômica) e retorna quantos foram salvos; sem jobs, remove o arquivo
+func SavePending(path string, reports []ShutdownReport) (int, error) {
+	var jobs []PendingJob
+	for _, report := range reports {
+		for _, file := range report.Abandoned {
+			jobs = append(jobs, PendingJob{Monitor: report.Monitor, File: file, Abandoned: true})
+		}
+		for _, file := range report.Pending {
+			jobs = append(jobs, PendingJob{Monitor: report.Monitor, File: file})
+		}
+	}
+
+	if len(jobs) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	data, err := json.MarshalIndent(pendingFile{SavedAt: time.Now(), Jobs: jobs}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create pending file directory: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return 0, err
+	}
+	return len(jobs), nil
+}
+
+// LoadPending lê e remove o arquivo de jobs pendentes do último shutdown
+// Retorna nenhum job se o arquivo não existir
+func LoadPending(path string) ([]PendingJob, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending pendingFile
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	// Os jobs passam a ser do worker pool; um novo shutdown grava o arquivo de novo
+	if err := os.Remove(path); err != nil {
+		return nil, err
+	}
+	return pending.Jobs, nil
+}
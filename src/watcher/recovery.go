@@ -0,0 +1,189 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// sourcePollInterval é o intervalo de verificação do source_path
+// (detecta unmount/remount e ativa monitores em espera)
+const sourcePollInterval = 5 * time.Second
+
+// Estados possíveis de um FileWatcher
+const (
+	StateRunning  = "running"  // source_path monitorado normalmente
+	StateWaiting  = "waiting"  // source_path indisponível, aguardando ele aparecer
+	StateDegraded = "degraded" // loop de eventos terminou ou travou, ou destino sem permissão de gravação (ver Status)
+)
+
+// State retorna o estado atual do watcher
+func (fw *FileWatcher) State() string {
+	fw.stateMu.Lock()
+	defer fw.stateMu.Unlock()
+	return fw.state
+}
+
+// setState altera o estado do watcher
+func (fw *FileWatcher) setState(state string) {
+	fw.stateMu.Lock()
+	fw.state = state
+	fw.stateMu.Unlock()
+}
+
+// WatchCount retorna quantos diretórios estão sendo monitorados
+func (fw *FileWatcher) WatchCount() int {
+	fw.watchMu.Lock()
+	defer fw.watchMu.Unlock()
+	return len(fw.watched)
+}
+
+// watchDir adiciona um diretório ao fsnotify e registra no conjunto de diretórios monitorados
+// Com files false os arquivos do diretório não são processados: o watch serve apenas para
+// detectar novos subdiretórios (diretório fora de include_dirs com um diretório incluído abaixo)
+func (fw *FileWatcher) watchDir(dir string, files bool) error {
+	if err := fw.watcher.Add(dir); err != nil {
+		return err
+	}
+
+	fw.watchMu.Lock()
+	fw.watched[dir] = files
+	fw.watchMu.Unlock()
+
+	return nil
+}
+
+// watchesFiles verifica se os arquivos do diretório são processados
+func (fw *FileWatcher) watchesFiles(dir string) bool {
+	fw.watchMu.Lock()
+	defer fw.watchMu.Unlock()
+	return fw.watched[dir]
+}
+
+// isWatched verifica se o path é um diretório monitorado
+func (fw *FileWatcher) isWatched(path string) bool {
+	fw.watchMu.Lock()
+	defer fw.watchMu.Unlock()
+	_, ok := fw.watched[path]
+	return ok
+}
+
+// unwatchTree remove os watches de um diretório e de todos os seus subdiretórios
+func (fw *FileWatcher) unwatchTree(root string) int {
+	fw.watchMu.Lock()
+	defer fw.watchMu.Unlock()
+
+	removed := 0
+	for dir := range fw.watched {
+		if dir != root && !strings.HasPrefix(dir, root+string(os.PathSeparator)) {
+			continue
+		}
+
+		// O fsnotify já descarta watches de diretórios removidos; erros aqui são esperados
+		_ = fw.watcher.Remove(dir)
+		delete(fw.watched, dir)
+		removed++
+	}
+
+	return removed
+}
+
+// activate registra os watches do source_path e coloca o monitor em execução
+func (fw *FileWatcher) activate() error {
+	info, err := os.Stat(fw.config.SourcePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("source_path is not a directory: %s", fw.config.SourcePath)
+	}
+
+	if err := fw.addPath(fw.config.SourcePath, true, fw.config.Recursive); err != nil {
+		fw.unwatchTree(fw.config.SourcePath)
+		return err
+	}
+
+	fw.sourceInfo = info
+	fw.setState(StateRunning)
+
+	fw.logger.Info("Watches registered",
+		"monitor", fw.config.Name,
+		"watches", fw.WatchCount(),
+	)
+
+	return nil
+}
+
+// deactivate remove todos os watches e coloca o monitor em espera pelo source_path
+func (fw *FileWatcher) deactivate(reason string) {
+	fw.unwatchTree(fw.config.SourcePath)
+	fw.sourceInfo = nil
+	fw.setState(StateWaiting)
+
+	fw.logger.Warn("Source path unavailable, monitor waiting for it to reappear",
+		"monitor", fw.config.Name,
+		"path", fw.config.SourcePath,
+		"reason", reason,
+	)
+}
+
+// checkSource verifica o source_path periodicamente (chamado pelo watchLoop)
+// Ativa monitores em espera e re-registra os watches se o source_path foi substituído ou remontado
+func (fw *FileWatcher) checkSource() {
+	info, err := os.Stat(fw.config.SourcePath)
+
+	switch fw.State() {
+	case StateWaiting:
+		if err != nil {
+			return
+		}
+		if err := fw.activate(); err != nil {
+			fw.logger.Warn("Failed to activate monitor", "monitor", fw.config.Name, "error", err)
+			return
+		}
+		fw.logger.Info("Source path available, monitor activated",
+			"monitor", fw.config.Name,
+			"path", fw.config.SourcePath,
+		)
+
+		// Processar arquivos que chegaram enquanto o monitor estava em espera
+		fw.requestRescan("source path activated")
+
+	case StateRunning:
+		if err != nil {
+			fw.deactivate(err.Error())
+			return
+		}
+
+		// Diretório diferente no mesmo path (remount) ou watch descartado pelo kernel (unmount)
+		if fw.sourceInfo != nil && os.SameFile(fw.sourceInfo, info) && fw.isWatched(fw.config.SourcePath) {
+			return
+		}
+
+		fw.logger.Warn("Source path was replaced or remounted, re-registering watches",
+			"monitor", fw.config.Name,
+			"path", fw.config.SourcePath,
+		)
+		fw.unwatchTree(fw.config.SourcePath)
+		if err := fw.activate(); err != nil {
+			fw.deactivate(err.Error())
+			return
+		}
+		fw.requestRescan("source path remounted")
+	}
+}
+
+// handleWatchedDirGone trata a remoção ou renomeação de um diretório monitorado
+func (fw *FileWatcher) handleWatchedDirGone(path string) {
+	if path == fw.config.SourcePath {
+		fw.deactivate("source path removed or renamed")
+		return
+	}
+
+	removed := fw.unwatchTree(path)
+	fw.logger.Debug("Watched directory removed or renamed",
+		"path", path,
+		"watches_removed", removed,
+	)
+}
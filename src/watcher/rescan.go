@@ -0,0 +1,139 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// rescanMinInterval é o intervalo mínimo entre dois rescans do mesmo monitor
+// Vários pedidos nesse intervalo (ex: overflows seguidos) resultam em um único rescan
+const rescanMinInterval = 15 * time.Second
+
+// requestRescan agenda um rescan do monitor, respeitando o intervalo mínimo
+func (fw *FileWatcher) requestRescan(reason string) {
+	fw.rescanMu.Lock()
+	defer fw.rescanMu.Unlock()
+
+	if fw.rescanScheduled {
+		fw.logger.Debug("Rescan already scheduled", "monitor", fw.config.Name, "reason", reason)
+		return
+	}
+	fw.rescanScheduled = true
+
+	wait := time.Until(fw.lastRescan.Add(rescanMinInterval))
+	if wait < 0 {
+		wait = 0
+	}
+
+	fw.logger.Info("Rescan scheduled",
+		"monitor", fw.config.Name,
+		"reason", reason,
+		"in", wait.String(),
+	)
+
+	time.AfterFunc(wait, func() {
+		fw.rescan(reason)
+	})
+}
+
+// rescan percorre o source_path, re-registra watches ausentes e envia
+// para processamento os arquivos que possam ter perdido eventos
+// Nunca há duas varreduras simultâneas do mesmo monitor: um rescan pedido durante uma
+// varredura é agendado de novo quando ela termina
+func (fw *FileWatcher) rescan(reason string) {
+	fw.rescanMu.Lock()
+	fw.rescanScheduled = false
+	if fw.rescanRunning {
+		fw.rescanAgain = reason
+		fw.rescanMu.Unlock()
+		fw.logger.Debug("Rescan already running, repeating it afterwards", "monitor", fw.config.Name, "reason", reason)
+		return
+	}
+	fw.rescanRunning = true
+	fw.lastRescan = time.Now()
+	fw.rescanMu.Unlock()
+
+	defer func() {
+		fw.rescanMu.Lock()
+		fw.rescanRunning = false
+		again := fw.rescanAgain
+		fw.rescanAgain = ""
+		fw.rescanMu.Unlock()
+		if again != "" {
+			fw.requestRescan(again)
+		}
+	}()
+
+	if fw.isStopped() || fw.State() != StateRunning {
+		return
+	}
+
+	fw.logger.Info("Rescan started", "monitor", fw.config.Name, "reason", reason)
+	start := time.Now()
+	root := fw.config.SourcePath
+	files, watchesAdded := 0, 0
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fw.logger.Warn("Error walking path during rescan", "path", path, "error", err)
+			return nil
+		}
+
+		// Interromper se o watcher foi parado durante o rescan
+		if fw.isStopped() {
+			return filepath.SkipAll
+		}
+
+		if info.IsDir() {
+			if path == root {
+				return nil
+			}
+			if !fw.config.Recursive {
+				return filepath.SkipDir
+			}
+
+			watch, descend, _ := fw.shouldWatchDir(path)
+			if (watch || descend) && !fw.isWatched(path) {
+				if err := fw.watchDir(path, watch); err != nil {
+					fw.logger.Warn("Failed to watch subdirectory", "path", path, "error", err)
+				} else {
+					fw.loadIgnoreFile(path)
+					watchesAdded++
+				}
+			}
+			if !descend {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Arquivos em diretórios não monitorados (ex: fora de include_dirs) não são processados
+		if !fw.watchesFiles(filepath.Dir(path)) {
+			return nil
+		}
+		if fw.ignore.Match(path, false) {
+			return nil
+		}
+
+		files++
+		fw.considerFile(path)
+		return nil
+	})
+	if err != nil {
+		fw.logger.Error("Rescan failed", "monitor", fw.config.Name, "error", err)
+		return
+	}
+
+	fw.logger.Info("Rescan completed",
+		"monitor", fw.config.Name,
+		"files", files,
+		"watches_added", watchesAdded,
+		"duration", time.Since(start).String(),
+	)
+}
+
+// isStopped verifica se o watcher já foi parado
+func (fw *FileWatcher) isStopped() bool {
+	return fw.ctx.Err() != nil
+}
@@ -0,0 +1,145 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gaa/file-organizer/src/processor"
+)
+
+// loopStallTimeout é o tempo sem iterações do watchLoop a partir do qual ele é considerado travado
+// Sem eventos, os tickers garantem uma iteração a cada sourcePollInterval; o tempo extra cobre
+// as esperas de IsFileReady durante o tratamento de um evento
+const loopStallTimeout = 2 * time.Minute
+
+// Activity descreve o último evento de um tipo (arquivo movido, erro)
+type Activity struct {
+	Time   time.Time `json:"time"`
+	File   string    `json:"file"`
+	Detail string    `json:"detail,omitempty"` // Destino do arquivo ou mensagem de erro
+}
+
+// activityLog guarda a última atividade do watcher e do seu worker pool
+type activityLog struct {
+	mu        sync.Mutex
+	lastEvent time.Time
+	lastMove  *Activity
+	lastError *Activity
+	processed int // Arquivos processados com sucesso desde o início
+	failures  int // Arquivos cujo processamento falhou desde o início
+}
+
+// event registra o recebimento de um evento do sistema de arquivos
+func (a *activityLog) event() {
+	a.mu.Lock()
+	a.lastEvent = time.Now()
+	a.mu.Unlock()
+}
+
+// moved registra um arquivo processado com sucesso
+func (a *activityLog) moved(file, destination string) {
+	a.mu.Lock()
+	a.lastMove = &Activity{Time: time.Now(), File: file, Detail: destination}
+	a.processed++
+	a.mu.Unlock()
+}
+
+// failed registra um arquivo cujo processamento falhou
+func (a *activityLog) failed(file, message string) {
+	a.mu.Lock()
+	a.lastError = &Activity{Time: time.Now(), File: file, Detail: message}
+	a.failures++
+	a.mu.Unlock()
+}
+
+// Status descreve o estado de um FileWatcher (endpoint /status)
+type Status struct {
+	Monitor     string     `json:"monitor"`
+	Path        string     `json:"path"`
+	State       string     `json:"state"` // running, waiting ou degraded
+	Paused      bool       `json:"paused"`
+	Healthy     bool       `json:"healthy"`
+	Problems    []string   `json:"problems,omitempty"`
+	WatchedDirs int        `json:"watched_dirs"`
+	QueueDepth  int        `json:"queue_depth"`
+	Processed   int        `json:"files_processed"`
+	Failed      int        `json:"files_failed"`
+	LastEvent   *time.Time `json:"last_event,omitempty"`
+	LastMove    *Activity  `json:"last_move,omitempty"`
+	LastError   *Activity  `json:"last_error,omitempty"`
+}
+
+// Status retorna o estado atual do watcher
+// O watcher fica degraded (e não saudável) se não estiver vivo (ver Alive)
+// ou se algum destino das regras não aceita gravação
+func (fw *FileWatcher) Status() Status {
+	status := Status{
+		Monitor:     fw.config.Name,
+		Path:        fw.config.SourcePath,
+		State:       fw.State(),
+		Paused:      fw.Paused(),
+		WatchedDirs: fw.WatchCount(),
+		QueueDepth:  fw.workerPool.QueueLen(),
+	}
+
+	fw.activity.mu.Lock()
+	if !fw.activity.lastEvent.IsZero() {
+		lastEvent := fw.activity.lastEvent
+		status.LastEvent = &lastEvent
+	}
+	status.LastMove = fw.activity.lastMove
+	status.LastError = fw.activity.lastError
+	status.Processed = fw.activity.processed
+	status.Failed = fw.activity.failures
+	fw.activity.mu.Unlock()
+
+	if err := fw.Alive(); err != nil {
+		status.Problems = append(status.Problems, err.Error())
+	}
+
+	checked := make(map[string]bool)
+	for _, rule := range fw.config.Rules {
+		for _, dir := range rule.OutputDirs() {
+			if checked[dir] {
+				continue
+			}
+			checked[dir] = true
+			if err := processor.CheckWritable(dir); err != nil {
+				status.Problems = append(status.Problems, "destination "+err.Error())
+			}
+		}
+	}
+
+	status.Healthy = len(status.Problems) == 0
+	if !status.Healthy {
+		status.State = StateDegraded
+	}
+	return status
+}
+
+// Alive verifica se as goroutines do watcher estão funcionando (watchdog do systemd):
+// o loop de eventos não terminou nem está travado e o worker pool tem todos os workers
+func (fw *FileWatcher) Alive() error {
+	if fw.loopExited() {
+		return errors.New("watch loop exited")
+	}
+	if !fw.isStopped() {
+		if stalled := time.Since(time.Unix(0, fw.heartbeat.Load())); stalled > loopStallTimeout {
+			return fmt.Errorf("watch loop stalled for %s", stalled.Round(time.Second))
+		}
+	}
+	return fw.workerPool.Alive()
+}
+
+// loopExited indica se o loop de eventos terminou sem o watcher ter sido parado
+// (ex: o fsnotify fechou os canais)
+func (fw *FileWatcher) loopExited() bool {
+	select {
+	case <-fw.loopDoneCh:
+		return !fw.isStopped()
+	default:
+		return false
+	}
+}
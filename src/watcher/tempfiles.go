@@ -0,0 +1,127 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// deferredCheckInterval é o intervalo para reavaliar documentos adiados por lock files
+const deferredCheckInterval = 10 * time.Second
+
+// DefaultTempPatterns são os patterns de arquivos temporários sempre ignorados
+// Patterns extras podem ser adicionados por monitor via temp_patterns
+var DefaultTempPatterns = []string{
+	"*.tmp",
+	"*.temp",
+	"*.crdownload", // Chrome downloads
+	"*.part",       // Firefox downloads
+	"*.download",
+	"*.partial",
+	"~$*",       // Owner files do Microsoft Office
+	".~lock.*#", // Lock files do LibreOffice
+}
+
+// buildTempPatterns combina os patterns padrão com os patterns do monitor (em lowercase)
+func buildTempPatterns(extra []string) []string {
+	patterns := make([]string, 0, len(DefaultTempPatterns)+len(extra))
+	for _, p := range DefaultTempPatterns {
+		patterns = append(patterns, strings.ToLower(p))
+	}
+	for _, p := range extra {
+		patterns = append(patterns, strings.ToLower(p))
+	}
+	return patterns
+}
+
+// isTempFile verifica se o arquivo é temporário
+func (fw *FileWatcher) isTempFile(filename string) bool {
+	lowerFilename := strings.ToLower(filename)
+	for _, pattern := range fw.tempPatterns {
+		if ok, _ := filepath.Match(pattern, lowerFilename); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lockFileCandidates retorna os possíveis lock/owner files de um documento
+// Excel cria "~$Nome.xlsx", Word abrevia o nome ("~$me.docx") e LibreOffice cria ".~lock.Nome.xlsx#"
+func lockFileCandidates(path string) []string {
+	dir, name := filepath.Split(path)
+
+	candidates := []string{
+		filepath.Join(dir, "~$"+name),
+		filepath.Join(dir, ".~lock."+name+"#"),
+	}
+
+	runes := []rune(name)
+	if len(runes) > 2 {
+		candidates = append(candidates,
+			filepath.Join(dir, "~$"+string(runes[1:])),
+			filepath.Join(dir, "~$"+string(runes[2:])),
+		)
+	}
+
+	return candidates
+}
+
+// findLockFile retorna o lock file existente para o documento, ou "" se não houver
+func findLockFile(path string) string {
+	for _, candidate := range lockFileCandidates(path) {
+		if _, err := os.Lstat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// deferFile registra um documento que está aberto em outro programa
+func (fw *FileWatcher) deferFile(path, lockFile string) {
+	fw.deferredMu.Lock()
+	_, already := fw.deferred[path]
+	fw.deferred[path] = time.Now()
+	fw.deferredMu.Unlock()
+
+	if !already {
+		fw.logger.Info("File is open in another application, deferring until lock is released",
+			"file", filepath.Base(path),
+			"lock_file", filepath.Base(lockFile),
+		)
+	}
+}
+
+// retryDeferred reavalia os documentos adiados e processa os que não têm mais lock
+func (fw *FileWatcher) retryDeferred() {
+	fw.deferredMu.Lock()
+	paths := make([]string, 0, len(fw.deferred))
+	for path := range fw.deferred {
+		paths = append(paths, path)
+	}
+	fw.deferredMu.Unlock()
+
+	for _, path := range paths {
+		// Documento removido ou movido enquanto estava aberto
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fw.deferredMu.Lock()
+			delete(fw.deferred, path)
+			fw.deferredMu.Unlock()
+			fw.logger.Debug("Deferred file no longer exists", "file", path)
+			continue
+		}
+
+		// Ainda aberto
+		if findLockFile(path) != "" {
+			continue
+		}
+
+		fw.deferredMu.Lock()
+		delete(fw.deferred, path)
+		fw.deferredMu.Unlock()
+
+		fw.logger.Info("Lock released, processing deferred file", "file", filepath.Base(path))
+		fw.processFile(path)
+	}
+}
@@ -1,15 +1,37 @@
 package watcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/fsnotify/fsnotify"
+	"gaa/file-organizer/src/audit"
 	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/metrics"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Motivos de descarte de eventos e arquivos (label reason de gaa_events_filtered_total)
+const (
+	filterDestination = "destination"  // Evento dentro de uma pasta de destino
+	filterIgnoreFile  = "ignore_file"  // Alteração de um .gaaignore
+	filterOperation   = "operation"    // Chmod, remoção ou rename
+	filterVanished    = "vanished"     // Arquivo sumiu antes de ser examinado
+	filterIgnored     = "ignored"      // Corresponde aos patterns de ignore
+	filterDirectory   = "directory"    // Diretórios não são processados
+	filterHidden      = "hidden"       // Arquivo oculto
+	filterTempFile    = "temp_file"    // Arquivo temporário ou lock file
+	filterLocked      = "locked"       // Documento aberto em outro programa (adiado)
+	filterNotReady    = "not_ready"    // Arquivo continuou travado após as tentativas
+	filterDuplicate   = "duplicate"    // Arquivo já estava na fila
+	filterNotIncluded = "not_included" // Arquivo em diretório fora de include_dirs
 )
 
 // FileWatcher monitora uma pasta e detecta novos arquivos
@@ -18,36 +40,85 @@ type FileWatcher struct {
 	logger     *slog.Logger
 	watcher    *fsnotify.Watcher
 	workerPool *WorkerPool
+	ignore     *IgnoreMatcher
 	delay      time.Duration
-	doneCh     chan struct{}
+	ctx        context.Context    // Cancelado por Shutdown (ou pelo contexto do daemon)
+	cancel     context.CancelFunc // Para o watchLoop e os rescans
+	loopDoneCh chan struct{}      // fechado quando o watchLoop termina
+	heartbeat  atomic.Int64       // última iteração do watchLoop (UnixNano), para detectar travamentos
+	activity   *activityLog
+
+	tempPatterns []string
+
+	deferredMu sync.Mutex
+	deferred   map[string]time.Time // documentos aguardando a liberação do lock file
+
+	watchMu sync.Mutex
+	watched map[string]bool // diretórios atualmente monitorados (false = apenas para novos subdiretórios)
+
+	stateMu    sync.Mutex
+	state      string
+	paused     bool        // Pausado pelo socket de controle: arquivos não são enviados ao worker pool
+	sourceInfo os.FileInfo // identidade do source_path quando os watches foram registrados
+
+	rescanMu        sync.Mutex
+	rescanScheduled bool
+	rescanRunning   bool   // Uma varredura está em andamento
+	rescanAgain     string // Motivo de um rescan pedido durante a varredura, repetido ao fim dela
+	lastRescan      time.Time
 }
 
 // NewFileWatcher cria uma nova instância do file watcher
-func NewFileWatcher(monitor *config.Monitor, delay time.Duration, workerPoolSize int, logger *slog.Logger) (*FileWatcher, error) {
+// O cancelamento de ctx para o watcher e o worker pool sem esperar a fila (ver Shutdown)
+func NewFileWatcher(ctx context.Context, monitor *config.Monitor, delay time.Duration, workerPoolSize int, auditSink audit.Sink, logger *slog.Logger) (*FileWatcher, error) {
 	// Criar watcher do fsnotify
 	fsWatcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create fsnotify watcher: %w", err)
 	}
 
+	// Compilar patterns de ignore do monitor
+	ignore, err := NewIgnoreMatcher(monitor.SourcePath, monitor.Ignore)
+	if err != nil {
+		fsWatcher.Close()
+		return nil, fmt.Errorf("failed to compile ignore patterns: %w", err)
+	}
+
 	// Criar worker pool
-	workerPool := NewWorkerPool(workerPoolSize, logger)
-	workerPool.Start()
+	workerPool := NewWorkerPool(monitor.Name, workerPoolSize, auditSink, logger)
+	workerPool.Start(ctx)
 
 	fw := &FileWatcher{
 		config:     monitor,
 		logger:     logger,
 		watcher:    fsWatcher,
 		workerPool: workerPool,
+		ignore:     ignore,
 		delay:      delay,
-		doneCh:     make(chan struct{}),
+		loopDoneCh: make(chan struct{}),
+		activity:   workerPool.activity,
+
+		tempPatterns: buildTempPatterns(monitor.TempPatterns),
+		deferred:     make(map[string]time.Time),
+		watched:      make(map[string]bool),
 	}
+	fw.ctx, fw.cancel = context.WithCancel(ctx)
+
+	// Registrar o source_path (ou aguardar ele aparecer, se configurado)
+	if err := fw.activate(); err != nil {
+		if !monitor.WaitForSource {
+			fw.cancel()
+			fsWatcher.Close()
+			workerPool.Shutdown(ctx)
+			return nil, fmt.Errorf("failed to watch path: %w", err)
+		}
 
-	// Registrar o source_path
-	if err := fw.addPath(monitor.SourcePath, monitor.Recursive); err != nil {
-		fsWatcher.Close()
-		workerPool.Stop()
-		return nil, fmt.Errorf("failed to watch path: %w", err)
+		fw.setState(StateWaiting)
+		logger.Warn("Source path not available, monitor waiting for it to appear",
+			"monitor", monitor.Name,
+			"path", monitor.SourcePath,
+			"error", err,
+		)
 	}
 
 	return fw, nil
@@ -56,22 +127,38 @@ func NewFileWatcher(monitor *config.Monitor, delay time.Duration, workerPoolSize
 // isDestinationPath verifica se um path é destino de alguma regra
 func (fw *FileWatcher) isDestinationPath(path string) bool {
 	for _, rule := range fw.config.Rules {
-		// Verificar se path é igual ou está dentro do destino
-		if strings.HasPrefix(path, rule.Destination) {
-			return true
+		// Verificar se path é igual ou está dentro de algum destino (/dest não contém /destino)
+		for _, destination := range rule.OutputDirs() {
+			if _, ok := relativePath(destination, path); ok {
+				return true
+			}
 		}
 	}
 	return false
 }
 
+// loadIgnoreFile carrega o .gaaignore de um diretório, se o monitor usar arquivos de ignore
+func (fw *FileWatcher) loadIgnoreFile(dir string) {
+	if !fw.config.IgnoreFiles {
+		return
+	}
+
+	path := filepath.Join(dir, ignoreFileName)
+	if err := fw.ignore.LoadFile(path); err != nil {
+		fw.logger.Warn("Failed to load ignore file", "file", path, "error", err)
+	}
+}
+
 // addPath adiciona um path ao watcher, recursivamente se necessário
-func (fw *FileWatcher) addPath(path string, recursive bool) error {
+// files indica se os arquivos do próprio path são processados (ver watchDir)
+func (fw *FileWatcher) addPath(path string, files bool, recursive bool) error {
 	// Adicionar o path principal
-	if err := fw.watcher.Add(path); err != nil {
+	if err := fw.watchDir(path, files); err != nil {
 		return err
 	}
 
 	fw.logger.Debug("Watching path", "path", path)
+	fw.loadIgnoreFile(path)
 
 	// Se recursivo, adicionar todas as subpastas
 	if recursive {
@@ -81,21 +168,27 @@ func (fw *FileWatcher) addPath(path string, recursive bool) error {
 				return nil // Continuar mesmo com erro
 			}
 
-			// Adicionar apenas diretórios (exceto ocultos e destinos)
-			if info.IsDir() && !strings.HasPrefix(filepath.Base(walkPath), ".") {
-				// Não monitorar se for pasta de destino
-				if fw.isDestinationPath(walkPath) {
-					fw.logger.Debug("Skipping destination path", "path", walkPath)
-					return filepath.SkipDir
-				}
+			// Adicionar apenas diretórios
+			if !info.IsDir() || walkPath == path { // Não adicionar o path principal novamente
+				return nil
+			}
 
-				if walkPath != path { // Não adicionar o path principal novamente
-					if err := fw.watcher.Add(walkPath); err != nil {
-						fw.logger.Warn("Failed to watch subdirectory", "path", walkPath, "error", err)
-					} else {
-						fw.logger.Debug("Watching subdirectory", "path", walkPath)
-					}
+			watch, descend, reason := fw.shouldWatchDir(walkPath)
+			if watch || descend {
+				if err := fw.watchDir(walkPath, watch); err != nil {
+					fw.logger.Warn("Failed to watch subdirectory", "path", walkPath, "error", err)
+				} else if watch {
+					fw.logger.Debug("Watching subdirectory", "path", walkPath)
+				} else {
+					fw.logger.Debug("Watching subdirectory for new subdirectories only", "path", walkPath, "reason", reason)
 				}
+				fw.loadIgnoreFile(walkPath)
+			} else {
+				fw.logger.Debug("Skipping directory", "path", walkPath, "reason", reason)
+			}
+
+			if !descend {
+				return filepath.SkipDir
 			}
 			return nil
 		})
@@ -116,6 +209,7 @@ func (fw *FileWatcher) Start() error {
 	)
 
 	// Goroutine para processar eventos
+	fw.heartbeat.Store(time.Now().UnixNano())
 	go fw.watchLoop()
 
 	return nil
@@ -123,10 +217,23 @@ func (fw *FileWatcher) Start() error {
 
 // watchLoop é a goroutine principal que escuta eventos do fsnotify
 func (fw *FileWatcher) watchLoop() {
+	defer close(fw.loopDoneCh)
+
+	// Ticker para reavaliar documentos adiados mesmo sem eventos do lock file
+	deferredTicker := time.NewTicker(deferredCheckInterval)
+	defer deferredTicker.Stop()
+
+	// Ticker para verificar o source_path (unmount, remount, monitor em espera)
+	sourceTicker := time.NewTicker(sourcePollInterval)
+	defer sourceTicker.Stop()
+
 	for {
+		fw.heartbeat.Store(time.Now().UnixNano())
+
 		select {
 		case event, ok := <-fw.watcher.Events:
 			if !ok {
+				fw.channelClosed()
 				return // Canal fechado
 			}
 
@@ -135,32 +242,84 @@ func (fw *FileWatcher) watchLoop() {
 
 		case err, ok := <-fw.watcher.Errors:
 			if !ok {
+				fw.channelClosed()
 				return // Canal fechado
 			}
+
+			// Fila de eventos do kernel estourou: eventos foram perdidos, reconciliar com um rescan
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				fw.logger.Warn("Event queue overflow, some events were lost", "monitor", fw.config.Name)
+				fw.requestRescan("event queue overflow")
+				continue
+			}
+
 			fw.logger.Error("Watcher error", "error", err)
 
-		case <-fw.doneCh:
+		case <-deferredTicker.C:
+			fw.retryDeferred()
+
+		case <-sourceTicker.C:
+			fw.checkSource()
+
+		case <-fw.ctx.Done():
 			fw.logger.Debug("Watcher stopping")
 			return
 		}
 	}
 }
 
+// channelClosed registra o fim inesperado do watchLoop (canais do fsnotify fechados sem Stop)
+func (fw *FileWatcher) channelClosed() {
+	if !fw.isStopped() {
+		fw.logger.Error("Watcher event channels closed, monitor stopped watching", "monitor", fw.config.Name)
+	}
+}
+
 // handleEvent processa um evento do fsnotify
 func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
+	metrics.EventsReceived.Inc(fw.config.Name)
+	fw.activity.event()
+
 	// Filtro 0: Ignorar eventos de pastas de destino (proteção extra)
 	if fw.isDestinationPath(event.Name) {
 		fw.logger.Debug("Ignoring event from destination path", "path", event.Name)
+		fw.filtered(filterDestination)
+		return
+	}
+
+	// Arquivos .gaaignore alterados são recarregados (e nunca processados)
+	if fw.config.IgnoreFiles && filepath.Base(event.Name) == ignoreFileName {
+		fw.logger.Debug("Reloading ignore file", "file", event.Name, "op", event.Op.String())
+		fw.loadIgnoreFile(filepath.Dir(event.Name))
+		fw.filtered(filterIgnoreFile)
 		return
 	}
 
 	// Filtro 1: Ignorar eventos Chmod
 	if event.Op&fsnotify.Chmod == fsnotify.Chmod {
+		fw.filtered(filterOperation)
 		return
 	}
 
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		// Diretório monitorado removido ou renomeado (o novo nome chega como Create)
+		if fw.isWatched(event.Name) {
+			fw.handleWatchedDirGone(event.Name)
+			fw.filtered(filterOperation)
+			return
+		}
+
+		// Remoção de um lock/temp file pode liberar documentos adiados
+		if fw.isTempFile(filepath.Base(event.Name)) {
+			fw.retryDeferred()
+			fw.filtered(filterOperation)
+			return
+		}
+	}
+
 	// Filtro 2: Aceitar apenas Create e Write
 	if event.Op&fsnotify.Create != fsnotify.Create && event.Op&fsnotify.Write != fsnotify.Write {
+		fw.filtered(filterOperation)
 		return
 	}
 
@@ -170,75 +329,108 @@ func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
 		if !os.IsNotExist(err) {
 			fw.logger.Warn("Failed to stat file", "file", event.Name, "error", err)
 		}
+		fw.filtered(filterVanished)
 		return
 	}
 
-	// Filtro 3: Ignorar diretórios (processar apenas arquivos)
+	// Filtro 3: Ignorar paths que correspondem aos patterns de ignore do monitor
+	if fw.ignore.Match(event.Name, fileInfo.IsDir()) {
+		fw.logger.Debug("Ignoring path matched by ignore patterns", "path", event.Name)
+		fw.filtered(filterIgnored)
+		return
+	}
+
+	// Filtro 4: Ignorar diretórios (processar apenas arquivos)
 	if fileInfo.IsDir() {
-		// Se for recursivo e for um novo diretório, adicionar ao watcher
-		// (exceto se for pasta de destino)
+		fw.filtered(filterDirectory)
+
+		// Se for recursivo e for um novo diretório (ou uma árvore renomeada/movida para cá),
+		// adicionar ao watcher com todos os subdiretórios (exceto se for pasta de destino)
 		if fw.config.Recursive && event.Op&fsnotify.Create == fsnotify.Create {
-			if !fw.isDestinationPath(event.Name) {
-				if err := fw.watcher.Add(event.Name); err != nil {
+			// Diretórios fora de include_dirs que podem conter um diretório incluído também são
+			// monitorados, para detectar os subdiretórios incluídos criados depois
+			if watch, descend, reason := fw.shouldWatchDir(event.Name); watch || descend {
+				if err := fw.addPath(event.Name, watch, true); err != nil {
 					fw.logger.Warn("Failed to watch new subdirectory", "path", event.Name, "error", err)
 				} else {
 					fw.logger.Debug("Now watching new subdirectory", "path", event.Name)
 				}
 			} else {
-				fw.logger.Debug("Skipping new destination directory", "path", event.Name)
+				fw.logger.Debug("Skipping new subdirectory", "path", event.Name, "reason", reason)
 			}
 		}
 		return
 	}
 
-	// Filtro 4: Ignorar arquivos ocultos (começam com ".")
-	filename := filepath.Base(event.Name)
+	// Filtro 5: Ignorar arquivos de diretórios monitorados só para detectar novos subdiretórios
+	if !fw.watchesFiles(filepath.Dir(event.Name)) {
+		fw.filtered(filterNotIncluded)
+		return
+	}
+
+	fw.logger.Debug("File event detected", "file", event.Name, "op", event.Op.String())
+
+	fw.considerFile(event.Name)
+}
+
+// considerFile aplica os filtros de arquivo e envia o arquivo para processamento
+func (fw *FileWatcher) considerFile(path string) {
+	// Filtro 6: Ignorar arquivos ocultos (começam com ".")
+	filename := filepath.Base(path)
 	if strings.HasPrefix(filename, ".") {
 		fw.logger.Debug("Ignoring hidden file", "file", filename)
+		fw.filtered(filterHidden)
 		return
 	}
 
-	// Filtro 5: Ignorar arquivos temporários
+	// Filtro 7: Ignorar arquivos temporários
 	if fw.isTempFile(filename) {
 		fw.logger.Debug("Ignoring temporary file", "file", filename)
+		fw.filtered(filterTempFile)
 		return
 	}
 
-	// Verificar se arquivo está pronto para ser processado
-	fw.logger.Debug("File event detected", "file", event.Name, "op", event.Op.String())
+	// Filtro 8: Adiar documentos abertos em outro programa (lock/owner file presente)
+	if lockFile := findLockFile(path); lockFile != "" {
+		fw.deferFile(path, lockFile)
+		fw.filtered(filterLocked)
+		return
+	}
+
+	fw.processFile(path)
+}
+
+// processFile verifica se o arquivo está pronto e envia para o worker pool
+func (fw *FileWatcher) processFile(path string) {
+	filename := filepath.Base(path)
+
+	if fw.Paused() {
+		fw.logger.Debug("Monitor paused, file left for the rescan after resume", "file", filename)
+		fw.filtered(filterPaused)
+		return
+	}
 
-	if fw.IsFileReady(event.Name) {
+	if fw.IsFileReady(path) {
 		fw.logger.Debug("File ready for processing", "file", filename)
 
 		// Enviar job para worker pool
-		fw.workerPool.Submit(Job{
-			FilePath: event.Name,
+		err := fw.workerPool.Submit(Job{
+			FilePath: path,
+			Monitor:  fw.config.Name,
 			Rules:    fw.config.Rules,
 		})
+		if err != nil {
+			fw.logger.Warn("File not submitted", "file", filename, "error", err)
+		}
 	} else {
 		fw.logger.Warn("File not ready or locked", "file", filename)
+		fw.filtered(filterNotReady)
 	}
 }
 
-// isTempFile verifica se o arquivo é temporário
-func (fw *FileWatcher) isTempFile(filename string) bool {
-	tempExtensions := []string{
-		".tmp",
-		".temp",
-		".crdownload", // Chrome downloads
-		".part",       // Firefox downloads
-		".download",
-		".partial",
-	}
-
-	lowerFilename := strings.ToLower(filename)
-	for _, ext := range tempExtensions {
-		if strings.HasSuffix(lowerFilename, ext) {
-			return true
-		}
-	}
-
-	return false
+// filtered contabiliza um evento ou arquivo descartado antes do processamento
+func (fw *FileWatcher) filtered(reason string) {
+	metrics.EventsFiltered.Inc(fw.config.Name, reason)
 }
 
 // IsFileReady verifica se um arquivo está pronto para ser processado
@@ -288,20 +480,22 @@ func (fw *FileWatcher) IsFileReady(path string) bool {
 	return false // Arquivo travado ou corrompido após todas as tentativas
 }
 
-// Stop para o watcher gracefully
-func (fw *FileWatcher) Stop() {
+// Shutdown para o watcher e dá aos jobs pendentes até ctx terminar para serem concluídos
+// Retorna o que aconteceu com cada job (concluído, abandonado ou pendente)
+func (fw *FileWatcher) Shutdown(ctx context.Context) ShutdownReport {
 	fw.logger.Info("Stopping file watcher", "monitor", fw.config.Name)
 
 	// Sinalizar para a goroutine parar
-	close(fw.doneCh)
+	fw.cancel()
 
 	// Fechar o watcher do fsnotify
 	if err := fw.watcher.Close(); err != nil {
 		fw.logger.Error("Error closing watcher", "error", err)
 	}
 
-	// Parar worker pool (aguarda jobs terminarem)
-	fw.workerPool.Stop()
+	// Parar worker pool (aguarda os jobs até ctx terminar)
+	report := fw.workerPool.Shutdown(ctx)
 
 	fw.logger.Debug("File watcher stopped", "monitor", fw.config.Name)
+	return report
 }
@@ -1,54 +1,150 @@
 package watcher
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"gaa/file-organizer/src/audit"
 	"gaa/file-organizer/src/config"
+	"gaa/file-organizer/src/metrics"
 	"gaa/file-organizer/src/processor"
 )
 
+// failurePanic é a classe de erro de um job interrompido por panic (label class de gaa_failures_total)
+const failurePanic = "panic"
+
+// idlePollInterval é o intervalo de verificação de WaitIdle
+const idlePollInterval = 100 * time.Millisecond
+
+// shutdownGrace é quanto o Shutdown espera, depois do prazo, os workers interrompidos retornarem
+const shutdownGrace = 5 * time.Second
+
+// ErrPoolStopped indica que o worker pool está em shutdown e não aceita novos jobs
+var ErrPoolStopped = errors.New("worker pool is shutting down")
+
 // Job representa uma tarefa de processamento de arquivo
 type Job struct {
 	FilePath string
+	Monitor  string
 	Rules    []config.Rule
+
+	queuedAt  time.Time // Quando o job entrou na fila (métrica de espera)
+	startedAt time.Time // Quando um worker começou a processar o job
+}
+
+// JobInfo descreve um job pendente ou em processamento (comando queue do ctl)
+type JobInfo struct {
+	File      string    `json:"file"`
+	QueuedAt  time.Time `json:"queued_at"`
+	Worker    int       `json:"worker"` // Apenas para jobs em processamento
+	StartedAt time.Time `json:"started_at,omitzero"`
 }
 
 // WorkerPool gerencia um pool de goroutines para processar arquivos
 type WorkerPool struct {
+	name    string // Nome do monitor dono do pool (label das métricas)
 	jobsCh  chan Job
 	workers int
+	audit   audit.Sink
 	logger  *slog.Logger
 	wg      sync.WaitGroup
-	stopCh  chan struct{}
+	ctx     context.Context    // Cancelado para parar dispatcher e workers imediatamente
+	cancel  context.CancelFunc // Chamado por Shutdown
+	alive   atomic.Int32       // goroutines do pool em execução (workers + dispatcher)
+
+	// Fila pendente sem limite: Submit nunca bloqueia quem produz os jobs (watchLoop)
+	queueMu      sync.Mutex
+	queue        []Job
+	queued       map[string]Job // jobs aguardando um worker, na fila ou no canal (evita jobs duplicados)
+	running      map[int]Job    // jobs em processamento, por worker
+	interrupted  []string       // jobs interrompidos pelo shutdown (arquivo ainda na origem)
+	queueCh      chan struct{}  // sinaliza ao dispatcher que há jobs novos
+	closed       bool           // Shutdown iniciado: Submit recusa novos jobs
+	dispatcherWg sync.WaitGroup
+
+	activity *activityLog // Último arquivo movido e último erro (endpoint /status)
 }
 
-// NewWorkerPool cria um novo worker pool
-func NewWorkerPool(workers int, logger *slog.Logger) *WorkerPool {
+// NewWorkerPool cria um novo worker pool para o monitor name
+// auditSink recebe um registro por arquivo processado (audit.Discard desativa)
+func NewWorkerPool(name string, workers int, auditSink audit.Sink, logger *slog.Logger) *WorkerPool {
 	// Buffer = 2x workers para evitar bloqueio
 	jobsCh := make(chan Job, workers*2)
 
 	return &WorkerPool{
+		name:    name,
 		jobsCh:  jobsCh,
 		workers: workers,
+		audit:   auditSink,
 		logger:  logger,
-		stopCh:  make(chan struct{}),
+		queued:  make(map[string]Job),
+		running: make(map[int]Job),
+		queueCh: make(chan struct{}, 1),
+
+		activity: &activityLog{},
 	}
 }
 
 // Start inicia todos os workers do pool
-func (wp *WorkerPool) Start() {
+// O cancelamento de ctx para os workers sem esperar a fila (ver Shutdown)
+func (wp *WorkerPool) Start(ctx context.Context) {
 	wp.logger.Info("Starting worker pool", "workers", wp.workers)
 
+	wp.ctx, wp.cancel = context.WithCancel(ctx)
+
+	wp.alive.Store(int32(wp.workers + 1))
 	for i := 0; i < wp.workers; i++ {
 		wp.wg.Add(1)
 		go wp.worker(i)
 	}
+
+	metrics.QueueDepth.SetFunc(func() float64 { return float64(wp.QueueLen()) }, wp.name)
+
+	wp.dispatcherWg.Add(1)
+	go wp.dispatcher()
+}
+
+// dispatcher move os jobs da fila pendente para o canal dos workers
+func (wp *WorkerPool) dispatcher() {
+	defer wp.dispatcherWg.Done()
+	defer wp.alive.Add(-1)
+
+	for {
+		wp.queueMu.Lock()
+		if len(wp.queue) == 0 {
+			wp.queueMu.Unlock()
+
+			// Aguardar novos jobs
+			select {
+			case <-wp.queueCh:
+				continue
+			case <-wp.ctx.Done():
+				return
+			}
+		}
+		job := wp.queue[0]
+		wp.queue = wp.queue[1:]
+		wp.queueMu.Unlock()
+
+		select {
+		case wp.jobsCh <- job:
+		case <-wp.ctx.Done():
+			return
+		}
+	}
 }
 
 // worker é a goroutine que processa jobs
 func (wp *WorkerPool) worker(id int) {
 	defer wp.wg.Done()
+	defer wp.alive.Add(-1)
 
 	wp.logger.Debug("Worker started", "worker_id", id)
 
@@ -60,90 +156,352 @@ func (wp *WorkerPool) worker(id int) {
 				return // Canal fechado - shutdown
 			}
 
-			// Processar job com error recovery
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						wp.logger.Error("Worker panic recovered",
-							"worker_id", id,
-							"panic", r,
-							"file", job.FilePath,
-						)
-					}
-				}()
-
-				wp.logger.Debug("Worker processing file",
-					"worker_id", id,
-					"file", job.FilePath,
-				)
-
-				// Matching + Move
-				rule := processor.MatchRule(job.FilePath, job.Rules)
-				if rule == nil {
-					wp.logger.Debug("No matching rule for file",
-						"worker_id", id,
-						"file", job.FilePath,
-					)
-					return
-				}
-
-				wp.logger.Info("Worker matched rule",
-					"worker_id", id,
-					"file", job.FilePath,
-					"rule", rule.Name,
-				)
-
-				err := processor.MoveFile(
-					job.FilePath,
-					rule.Destination,
-					rule.ConflictStrategy,
-					wp.logger,
-				)
-				if err != nil {
-					wp.logger.Error("Worker failed to move file",
-						"worker_id", id,
-						"file", job.FilePath,
-						"error", err,
-					)
-				} else {
-					wp.logger.Info("Worker completed job",
-						"worker_id", id,
-						"file", job.FilePath,
-					)
-				}
-			}()
-
-		case <-wp.stopCh:
+			// O select escolhe ao acaso entre os casos prontos: após o cancelamento o job fica pendente
+			if wp.ctx.Err() != nil {
+				wp.logger.Debug("Worker stopping (stop signal)", "worker_id", id)
+				return
+			}
+
+			wp.queueMu.Lock()
+			delete(wp.queued, job.FilePath)
+			job.startedAt = time.Now()
+			wp.running[id] = job
+			wp.queueMu.Unlock()
+
+			interrupted := wp.process(id, job)
+
+			wp.queueMu.Lock()
+			delete(wp.running, id)
+			if interrupted {
+				wp.interrupted = append(wp.interrupted, job.FilePath)
+			}
+			wp.queueMu.Unlock()
+
+		case <-wp.ctx.Done():
 			wp.logger.Debug("Worker stopping (stop signal)", "worker_id", id)
 			return
 		}
 	}
 }
 
-// Submit envia um job para o pool
-func (wp *WorkerPool) Submit(job Job) {
+// process aplica a regra correspondente ao arquivo e registra o resultado na auditoria
+// Retorna true se o shutdown interrompeu o job: nada é registrado e o job é salvo para a próxima execução
+func (wp *WorkerPool) process(id int, job Job) (interrupted bool) {
+	record := audit.Record{
+		Time:     time.Now(),
+		Monitor:  job.Monitor,
+		Source:   job.FilePath,
+		WorkerID: id,
+	}
+
+	if !job.queuedAt.IsZero() {
+		metrics.QueueWait.Observe(record.Time.Sub(job.queuedAt).Seconds(), job.Monitor)
+	}
+	metrics.JobsInFlight.Add(1, job.Monitor)
+	failureClass := "" // Classe do erro, se o job falhar
+
+	defer func() {
+		metrics.JobsInFlight.Add(-1, job.Monitor)
+		if interrupted {
+			return
+		}
+		metrics.JobsProcessed.Inc(job.Monitor, record.Rule, record.Outcome)
+		switch record.Outcome {
+		case audit.OutcomeFailed:
+			metrics.Failures.Inc(job.Monitor, record.Rule, failureClass)
+			wp.activity.failed(job.FilePath, record.Error)
+		case audit.OutcomeSuccess, audit.OutcomeOverflow:
+			destination := ""
+			if len(record.Destinations) > 0 {
+				destination = record.Destinations[len(record.Destinations)-1]
+			}
+			wp.activity.moved(job.FilePath, destination)
+		}
+
+		record.DurationMs = time.Since(record.Time).Milliseconds()
+		if err := wp.audit.Write(record); err != nil {
+			wp.logger.Error("Failed to write audit record", "file", job.FilePath, "error", err)
+		}
+	}()
+
+	// Processar job com error recovery
+	defer func() {
+		if r := recover(); r != nil {
+			wp.logger.Error("Worker panic recovered",
+				"worker_id", id,
+				"panic", r,
+				"file", job.FilePath,
+			)
+			record.Outcome = audit.OutcomeFailed
+			record.Error = fmt.Sprintf("panic: %v", r)
+			failureClass = failurePanic
+		}
+	}()
+
+	wp.logger.Debug("Worker processing file",
+		"worker_id", id,
+		"file", job.FilePath,
+	)
+
+	// Matching + Move
+	rule := processor.MatchRule(job.FilePath, job.Rules)
+	if rule == nil {
+		wp.logger.Debug("No matching rule for file",
+			"worker_id", id,
+			"file", job.FilePath,
+		)
+		record.Outcome = audit.OutcomeUnmatched
+		if info, err := os.Stat(job.FilePath); err == nil {
+			record.Size = info.Size()
+		}
+		return false
+	}
+	record.Rule = rule.Name
+	metrics.RuleHits.Inc(job.Monitor, rule.Name)
+
+	wp.logger.Info("Worker matched rule",
+		"worker_id", id,
+		"file", job.FilePath,
+		"rule", rule.Name,
+	)
+
+	start := time.Now()
+	result, err := processor.Execute(wp.ctx, job.FilePath, job.Monitor, rule, wp.logger)
+	metrics.MoveDuration.Observe(time.Since(start).Seconds(), job.Monitor, rule.Name)
+	record.Action = result.Action
+	record.Destinations = result.Destinations
+	record.Size = result.Size
+	record.SHA256 = result.SHA256
+
+	switch {
+	case err != nil && wp.ctx.Err() != nil && errors.Is(err, wp.ctx.Err()):
+		wp.logger.Warn("Job interrupted by shutdown, file left in place",
+			"worker_id", id,
+			"file", job.FilePath,
+			"error", err,
+		)
+		return true
+	case err != nil:
+		record.Outcome = audit.OutcomeFailed
+		record.Error = err.Error()
+		failureClass = processor.ErrorClass(err)
+		wp.logger.Error("Worker failed to process file",
+			"worker_id", id,
+			"file", job.FilePath,
+			"action", result.Action,
+			"error", err,
+		)
+		return false
+	case result.Overflow:
+		record.Outcome = audit.OutcomeOverflow
+	case result.Skipped:
+		record.Outcome = audit.OutcomeSkipped
+	default:
+		record.Outcome = audit.OutcomeSuccess
+	}
+
+	// Hash do conteúdo gravado, se a ação não o calculou (apenas com auditoria ativa)
+	if record.SHA256 == "" && len(record.Destinations) > 0 && wp.audit != audit.Discard {
+		if hash, _, err := processor.HashFile(record.Destinations[len(record.Destinations)-1]); err == nil {
+			record.SHA256 = hash
+		}
+	}
+
+	wp.logger.Info("Worker completed job",
+		"worker_id", id,
+		"file", job.FilePath,
+		"action", result.Action,
+		"destinations", len(result.Destinations),
+	)
+	return false
+}
+
+// Submit envia um job para o pool sem bloquear
+// Se o arquivo já estiver na fila, o job duplicado é descartado
+// Após o início do Shutdown retorna ErrPoolStopped
+func (wp *WorkerPool) Submit(job Job) error {
+	wp.queueMu.Lock()
+	if wp.closed {
+		wp.queueMu.Unlock()
+		return ErrPoolStopped
+	}
+	if _, ok := wp.queued[job.FilePath]; ok {
+		wp.queueMu.Unlock()
+		wp.logger.Debug("File already queued, skipping duplicate job", "file", job.FilePath)
+		metrics.EventsFiltered.Inc(job.Monitor, filterDuplicate)
+		return nil
+	}
+	job.queuedAt = time.Now()
+	wp.queue = append(wp.queue, job)
+	wp.queued[job.FilePath] = job
+	pending := len(wp.queue)
+	wp.queueMu.Unlock()
+	metrics.JobsQueued.Inc(job.Monitor)
+
+	// Acordar o dispatcher (sem bloquear se já houver um sinal pendente)
 	select {
-	case wp.jobsCh <- job:
-		wp.logger.Debug("Job submitted to worker pool", "file", job.FilePath)
+	case wp.queueCh <- struct{}{}:
 	default:
-		// Canal cheio - logar warning mas não bloquear
-		wp.logger.Warn("Worker pool channel full, job may be delayed", "file", job.FilePath)
-		wp.jobsCh <- job // Bloquear até ter espaço
+	}
+
+	if pending > wp.workers*2 {
+		wp.logger.Warn("Worker pool busy, job queued", "file", job.FilePath, "queue_depth", pending)
+	} else {
+		wp.logger.Debug("Job submitted to worker pool", "file", job.FilePath)
+	}
+	return nil
+}
+
+// QueueLen retorna quantos jobs aguardam na fila (incluindo os já entregues ao canal dos workers)
+func (wp *WorkerPool) QueueLen() int {
+	wp.queueMu.Lock()
+	defer wp.queueMu.Unlock()
+	return len(wp.queue) + len(wp.jobsCh)
+}
+
+// Jobs retorna os jobs aguardando um worker (em ordem de chegada) e os jobs em processamento
+func (wp *WorkerPool) Jobs() (pending []JobInfo, running []JobInfo) {
+	wp.queueMu.Lock()
+	defer wp.queueMu.Unlock()
+
+	for _, job := range wp.queued {
+		pending = append(pending, JobInfo{File: job.FilePath, QueuedAt: job.queuedAt})
+	}
+	for id, job := range wp.running {
+		running = append(running, JobInfo{File: job.FilePath, QueuedAt: job.queuedAt, Worker: id, StartedAt: job.startedAt})
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].QueuedAt.Before(pending[j].QueuedAt) })
+	sort.Slice(running, func(i, j int) bool { return running[i].Worker < running[j].Worker })
+	return pending, running
+}
+
+// WaitIdle aguarda até não haver jobs pendentes nem em processamento
+// Retorna false se timeout expirar antes
+func (wp *WorkerPool) WaitIdle(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return wp.waitIdle(ctx)
+}
+
+// waitIdle aguarda até não haver jobs pendentes nem em processamento
+// Retorna false se ctx terminar antes
+func (wp *WorkerPool) waitIdle(ctx context.Context) bool {
+	ticker := time.NewTicker(idlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		wp.queueMu.Lock()
+		idle := len(wp.queued) == 0 && len(wp.running) == 0
+		wp.queueMu.Unlock()
+
+		if idle {
+			return true
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
 	}
 }
 
-// Stop para o worker pool gracefully
-func (wp *WorkerPool) Stop() {
-	wp.logger.Info("Stopping worker pool")
+// Alive retorna um erro se alguma goroutine do pool terminou sem o pool ter sido parado
+func (wp *WorkerPool) Alive() error {
+	if wp.ctx.Err() != nil {
+		return nil
+	}
+
+	if alive, expected := wp.alive.Load(), int32(wp.workers+1); alive < expected {
+		return fmt.Errorf("worker pool has %d of %d goroutines running", alive, expected)
+	}
+	return nil
+}
+
+// ShutdownReport descreve o destino dos jobs de um worker pool no shutdown
+type ShutdownReport struct {
+	Monitor   string
+	Completed []string // Jobs processados durante o shutdown (com sucesso ou não)
+	Abandoned []string // Jobs em processamento quando o prazo expirou
+	Pending   []string // Jobs que não chegaram a um worker
+}
+
+// Shutdown para de aceitar jobs e aguarda a fila esvaziar até ctx terminar (prazo do shutdown
+// ou segundo sinal); em seguida para o dispatcher e os workers
+// Jobs ainda em processamento nesse momento são interrompidos no próximo ponto seguro (ver
+// processor.Execute) e aguardados por até shutdownGrace; os que não retornarem a tempo aparecem
+// como abandonados, assim como os interrompidos
+func (wp *WorkerPool) Shutdown(ctx context.Context) ShutdownReport {
+	wp.logger.Info("Stopping worker pool", "monitor", wp.name)
+
+	// Recusar novos jobs e guardar os que existem agora (para saber quais foram concluídos)
+	wp.queueMu.Lock()
+	wp.closed = true
+	outstanding := make(map[string]bool, len(wp.queued)+len(wp.running))
+	for path := range wp.queued {
+		outstanding[path] = true
+	}
+	for _, job := range wp.running {
+		outstanding[job.FilePath] = true
+	}
+	wp.queueMu.Unlock()
 
-	// Fechar canal de jobs (não aceitar mais trabalho)
+	drained := wp.waitIdle(ctx)
+
+	// Sinalizar dispatcher e workers para parar e fechar o canal de jobs após o dispatcher parar de enviar
+	wp.cancel()
+	wp.dispatcherWg.Wait()
 	close(wp.jobsCh)
 
-	// Sinalizar workers para parar
-	close(wp.stopCh)
+	// Com a fila vazia os workers terminam em seguida; após o prazo os jobs em andamento
+	// precisam de um instante para parar (e gravar a auditoria antes de os sinks fecharem)
+	if drained {
+		wp.wg.Wait()
+	} else if !wp.waitWorkers(shutdownGrace) {
+		wp.logger.Warn("Workers still running after shutdown grace period", "monitor", wp.name, "grace", shutdownGrace.String())
+	}
+	metrics.QueueDepth.Delete(wp.name)
 
-	// Aguardar todos os workers terminarem
-	wp.wg.Wait()
+	report := ShutdownReport{Monitor: wp.name}
+	pending, running := wp.Jobs()
+	for _, job := range pending {
+		report.Pending = append(report.Pending, job.File)
+		delete(outstanding, job.File)
+	}
+	for _, job := range running {
+		report.Abandoned = append(report.Abandoned, job.File)
+		delete(outstanding, job.File)
+	}
+	wp.queueMu.Lock()
+	for _, path := range wp.interrupted {
+		report.Abandoned = append(report.Abandoned, path)
+		delete(outstanding, path)
+	}
+	wp.queueMu.Unlock()
+	for path := range outstanding {
+		report.Completed = append(report.Completed, path)
+	}
+	sort.Strings(report.Completed)
+
+	wp.logger.Info("Worker pool stopped", "monitor", wp.name, "drained", drained)
+	return report
+}
 
-	wp.logger.Info("Worker pool stopped")
+// waitWorkers aguarda os workers retornarem por até timeout
+// Retorna false se algum ainda estiver em execução ao fim do prazo
+func (wp *WorkerPool) waitWorkers(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
 }